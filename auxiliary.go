@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+//a dfa the wfa are multiplied with before the accept set is built, so their states also know what the dfa read.
+//it is made for the number of symbols of each wfa and is an unweighted dwfa whose start state loops on the blank symbol
+type auxiliaryDFA func(symbols int) dwfa
+
+//the product of the wfa with the auxiliary dfa: its states are the pairs of states that can be reached from the pair of start
+//states and each transition has the weight of the transition of the wfa. Like addWFAMemory it keeps 1 as the dead state,
+//every pair with the dead state 1 of the wfa becomes state 1. Symbols the dfa has no transition for lead to the dead state
+func productWFA(wfa, aux dwfa) dwfa {
+	type pair struct {
+		state, auxState wfaState
+	}
+	start := pair{wfa.startState, aux.startState}
+	numbers := map[pair]wfaState{start: 0}
+	order := []pair{start}
+	number := func(p pair) wfaState {
+		if p.state == 1 {
+			return 1
+		}
+		if _, ok := numbers[p]; !ok {
+			numbers[p] = wfaState(len(order) + 1)
+			order = append(order, p)
+		}
+		return numbers[p]
+	}
+	result := dwfa{
+		symbols:     wfa.symbols,
+		startState:  0,
+		transitions: map[wfaState]map[symbol]wfaTransition{1: {}},
+		modulus:     wfa.modulus,
+	}
+	for j := 0; j < wfa.symbols; j++ {
+		result.transitions[1][symbol(j)] = wfaTransition{1, 0}
+	}
+	for i := 0; i < len(order); i++ {
+		from := numbers[order[i]]
+		result.transitions[from] = map[symbol]wfaTransition{}
+		for j := 0; j < wfa.symbols; j++ {
+			transition, ok := wfa.transitions[order[i].state][symbol(j)]
+			if !ok {
+				continue
+			}
+			auxTransition, ok := aux.transitions[order[i].auxState][symbol(j)]
+			if !ok {
+				result.transitions[from][symbol(j)] = wfaTransition{1, 0}
+				continue
+			}
+			result.transitions[from][symbol(j)] = wfaTransition{number(pair{transition.wfaState, auxTransition.wfaState}), transition.weight}
+		}
+	}
+	result.states = len(order) + 1
+	return result
+}
+
+func productVectorWFA(wfa vectorWFA, aux dwfa) vectorWFA {
+	result := vectorWFA{}
+	for _, coordinate := range wfa {
+		result = append(result, productWFA(coordinate, aux))
+	}
+	return result
+}
+
+//the wfa the accept set is built for: the weighted base wfa with the memory added and then multiplied with the auxiliary dfa
+func liftWFA(wfa dwfa, addedMemory int, options searchOptions) dwfa {
+	wfa = copyWFA(wfa)
+	for i := 0; i < addedMemory; i++ {
+		wfa = addWFAMemory(wfa)
+	}
+	if options.auxiliary != nil {
+		wfa = productWFA(wfa, options.auxiliary(wfa.symbols))
+	}
+	return wfa
+}
+
+func liftVectorWFA(wfa vectorWFA, addedMemory int, options searchOptions) vectorWFA {
+	wfa = copyVectorWFA(wfa)
+	for i := 0; i < addedMemory; i++ {
+		wfa = addVectorWFAMemory(wfa)
+	}
+	if options.auxiliary != nil {
+		wfa = productVectorWFA(wfa, options.auxiliary(wfa[0].symbols))
+	}
+	return wfa
+}
+
+//the last k symbols read, as a number in base symbols with the last one as the lowest digit.
+//the start state stands for k blanks
+func lastSymbolsDFA(k int) auxiliaryDFA {
+	return func(symbols int) dwfa {
+		states := 1
+		for i := 0; i < k; i++ {
+			states *= symbols
+		}
+		result := dwfa{states: states, symbols: symbols, startState: 0, transitions: map[wfaState]map[symbol]wfaTransition{}}
+		for i := 0; i < states; i++ {
+			result.transitions[wfaState(i)] = map[symbol]wfaTransition{}
+			for j := 0; j < symbols; j++ {
+				result.transitions[wfaState(i)][symbol(j)] = wfaTransition{wfaState((i*symbols + j) % states), 0}
+			}
+		}
+		return result
+	}
+}
+
+//the number of symbols read since the first non-blank one modulo k. The start state stands for only blanks so far,
+//the state i+1 for i modulo k
+func positionModuloDFA(k int) auxiliaryDFA {
+	return func(symbols int) dwfa {
+		result := dwfa{states: k + 1, symbols: symbols, startState: 0, transitions: map[wfaState]map[symbol]wfaTransition{0: {0: {0, 0}}}}
+		for j := 1; j < symbols; j++ {
+			result.transitions[0][symbol(j)] = wfaTransition{1, 0}
+		}
+		for i := 1; i <= k; i++ {
+			result.transitions[wfaState(i)] = map[symbol]wfaTransition{}
+			for j := 0; j < symbols; j++ {
+				result.transitions[wfaState(i)][symbol(j)] = wfaTransition{wfaState(i%k + 1), 0}
+			}
+		}
+		return result
+	}
+}
+
+//the auxiliary dfa "lastK" or "modK" from the built-in library, or else the dfa in the first line of the file of that name,
+//written like a wfa with all weights 0, e.g. "0,0;1,0_2,0;1,0_1,0;2,0"
+func parseAuxiliaryDFA(s string) (auxiliaryDFA, error) {
+	for prefix, library := range map[string]func(int) auxiliaryDFA{"last": lastSymbolsDFA, "mod": positionModuloDFA} {
+		if k, err := strconv.Atoi(strings.TrimPrefix(s, prefix)); strings.HasPrefix(s, prefix) && err == nil {
+			if k < 1 {
+				return nil, errorString("The auxiliary DFA \"" + s + "\" needs a positive size")
+			}
+			return library(k), nil
+		}
+	}
+	content, err := os.ReadFile(s)
+	if err != nil {
+		return nil, err
+	}
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	dfa, err := parseWFA(line)
+	if err != nil {
+		return nil, err
+	}
+	if transition, ok := dfa.transitions[dfa.startState][0]; !ok || transition.wfaState != dfa.startState {
+		return nil, errorString("The start state of the auxiliary DFA has to loop on the blank symbol: \"" + line + "\"")
+	}
+	for _, transitions := range dfa.transitions {
+		for _, transition := range transitions {
+			if transition.weight != 0 {
+				return nil, errorString("The auxiliary DFA has to be unweighted: \"" + line + "\"")
+			}
+		}
+	}
+	return func(int) dwfa { return dfa }, nil
+}
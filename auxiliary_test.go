@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProductWFA(t *testing.T) {
+	wfa, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	for _, name := range []string{"last1", "last2", "mod2", "mod3"} {
+		aux, _ := parseAuxiliaryDFA(name)
+		product := productWFA(wfa, aux(2))
+		if product.transitions[0][0] != (wfaTransition{0, 0}) || product.states < wfa.states {
+			t.Error(name, product)
+		}
+		//every word has the same weight and dies at the same time in the product
+		for _, word := range symbolStrings(2, 7) {
+			weights, productWeights := weightVector{0}, weightVector{0}
+			state := runVectorWFA(vectorWFA{wfa}, word, weights)
+			productState := runVectorWFA(vectorWFA{product}, word, productWeights)
+			if (state == 1) != (productState == 1) || state != 1 && weights[0] != productWeights[0] {
+				t.Error(name, word, state, productState, weights, productWeights)
+			}
+		}
+	}
+}
+
+func TestPositionModuloDFA(t *testing.T) {
+	aux := positionModuloDFA(3)(2)
+	//blanks before the first 1 don't count
+	if state := runVectorWFA(vectorWFA{aux}, []symbol{0, 0, 1, 0, 0, 1}, weightVector{0}); state != 1 {
+		t.Error(state)
+	}
+	if state := runVectorWFA(vectorWFA{aux}, []symbol{0, 1, 0, 1}, weightVector{0}); state != 3 {
+		t.Error(state)
+	}
+}
+
+func TestParseAuxiliaryDFA(t *testing.T) {
+	if aux, err := parseAuxiliaryDFA("last2"); err != nil || aux(3).states != 9 {
+		t.Fail()
+	}
+	if _, err := parseAuxiliaryDFA("mod0"); err == nil {
+		t.Fail()
+	}
+	dir := t.TempDir()
+	for content, valid := range map[string]bool{"0,0;1,0_1,0;0,0\n": true, "0,0;1,1_1,0;0,0\n": false, "1,0;1,0_1,0;0,0\n": false} {
+		fileName := filepath.Join(dir, "dfa.txt")
+		os.WriteFile(fileName, []byte(content), 0644)
+		if _, err := parseAuxiliaryDFA(fileName); (err == nil) != valid {
+			t.Error(content, err)
+		}
+	}
+}
+
+func TestAuxiliaryDFASearch(t *testing.T) {
+	//no wfa pair within 4 transitions proves this TM, but one does after multiplying both wfa with the last symbol
+	tm, _ := parseTM("1RB---_0LC0RB_1RA1LE_0LB0LB_1LB1LE")
+	aux, _ := parseAuxiliaryDFA("last1")
+	for _, auxiliary := range []auxiliaryDFA{nil, aux} {
+		options := searchOptions{auxiliary: auxiliary, allProofs: 1}.forTM()
+		for transitions := 2; transitions <= 4; transitions++ {
+			MITMWFARdecider(tm, transitions, 5, 5, 1, 0, 0, options, -1)
+		}
+		if (len(options.proofs.proofs) > 0) != (auxiliary != nil) {
+			t.Fatal(auxiliary != nil, len(options.proofs.proofs))
+		}
+		checkProofs(t, options.proofs)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+//numbers the classes in the order of their smallest state, so the start state 0 keeps its number
+func numberClasses(states int, key func(wfaState) (string, bool)) (map[wfaState]wfaState, int) {
+	numbers := map[string]wfaState{}
+	result := map[wfaState]wfaState{}
+	for i := 0; i < states; i++ {
+		k, ok := key(wfaState(i))
+		if !ok {
+			continue
+		}
+		if _, exists := numbers[k]; !exists {
+			numbers[k] = wfaState(len(numbers))
+		}
+		result[wfaState(i)] = numbers[k]
+	}
+	return result, len(numbers)
+}
+
+//the states that can be reached from the start state. The dead state 1 is kept anyway, so it keeps its number
+func reachableStates(wfa vectorWFA) (map[wfaState]wfaState, int) {
+	reachable := set[wfaState]{wfa[0].startState: {}, 1: {}}
+	todo := []wfaState{wfa[0].startState}
+	for len(todo) > 0 {
+		state := todo[0]
+		todo = todo[1:]
+		for _, transition := range wfa[0].transitions[state] {
+			if !reachable.contains(transition.wfaState) {
+				reachable.add(transition.wfaState)
+				todo = append(todo, transition.wfaState)
+			}
+		}
+	}
+	return numberClasses(wfa[0].states, func(state wfaState) (string, bool) {
+		return fmt.Sprint(state), reachable.contains(state)
+	})
+}
+
+//the coarsest partition of the states in which the states of a class have transitions with the same weights
+//into the same class on each symbol. Merging them keeps the weight of every word. The dead state 1 starts out
+//in a class of its own, since it rejects every word while other states with the same weights don't
+func bisimulationClasses(wfa vectorWFA) (map[wfaState]wfaState, int) {
+	classes, count := numberClasses(wfa[0].states, func(state wfaState) (string, bool) { return fmt.Sprint(state == 1), true })
+	for {
+		newClasses, newCount := numberClasses(wfa[0].states, func(state wfaState) (string, bool) {
+			key := fmt.Sprint(classes[state])
+			for j := 0; j < wfa[0].symbols; j++ {
+				key += fmt.Sprintf("_%v", classes[wfa[0].transitions[state][symbol(j)].wfaState])
+				for _, coordinate := range wfa {
+					key += fmt.Sprintf(",%v", coordinate.transitions[state][symbol(j)].weight)
+				}
+			}
+			return key, true
+		})
+		if newCount == count {
+			return newClasses, newCount
+		}
+		classes, count = newClasses, newCount
+	}
+}
+
+//the wfa with the states of each class merged into one. Classes are numbered from 0 and
+//states without a class are dropped, which is only possible if no remaining state has a transition to them
+func quotientWFA(wfa dwfa, classes map[wfaState]wfaState, count int) dwfa {
+	result := dwfa{
+		states:      count,
+		symbols:     wfa.symbols,
+		startState:  classes[wfa.startState],
+		transitions: map[wfaState]map[symbol]wfaTransition{},
+		modulus:     wfa.modulus,
+	}
+	for state, transitions := range wfa.transitions {
+		class, ok := classes[state]
+		if !ok {
+			continue
+		}
+		result.transitions[class] = map[symbol]wfaTransition{}
+		for symbol, transition := range transitions {
+			result.transitions[class][symbol] = wfaTransition{classes[transition.wfaState], transition.weight}
+		}
+	}
+	return result
+}
+
+func quotientVectorWFA(wfa vectorWFA, classes map[wfaState]wfaState, count int) vectorWFA {
+	result := vectorWFA{}
+	for _, coordinate := range wfa {
+		result = append(result, quotientWFA(coordinate, classes, count))
+	}
+	return result
+}
+
+//the wfa reweighted by a potential: each reachable state gets the weight of the path to it through the breadth first
+//search tree from the start state, trying the symbols in order, and each transition is reweighted by the potential of
+//where it starts minus the potential of where it ends. The tree transitions end up with weight 0.
+//every path to a state changes by minus the potential of that state, so the accept set of a reweighted pair only
+//needs the bounds of each config shifted. Wfa that only differ by a potential are the same after pushing
+func pushWeights(wfa vectorWFA) vectorWFA {
+	start := wfa[0].startState
+	potentials := map[wfaState]weightVector{start: make(weightVector, len(wfa))}
+	order := []wfaState{start}
+	for i := 0; i < len(order); i++ {
+		for j := 0; j < wfa[0].symbols; j++ {
+			transition, ok := wfa[0].transitions[order[i]][symbol(j)]
+			if _, seen := potentials[transition.wfaState]; !ok || seen {
+				continue
+			}
+			potential := weightVector{}
+			for k, coordinate := range wfa {
+				potential = append(potential, reduce(potentials[order[i]][k]+coordinate.transitions[order[i]][symbol(j)].weight, coordinate.modulus))
+			}
+			potentials[transition.wfaState] = potential
+			order = append(order, transition.wfaState)
+		}
+	}
+	result := copyVectorWFA(wfa)
+	for k, coordinate := range result {
+		for state, transitions := range coordinate.transitions {
+			from, ok := potentials[state]
+			if !ok {
+				continue
+			}
+			for symbol, transition := range transitions {
+				transitions[symbol] = wfaTransition{transition.wfaState, reduce(transition.weight+from[k]-potentials[transition.wfaState][k], coordinate.modulus)}
+			}
+		}
+	}
+	return result
+}
+
+//the wfa with its states numbered in the order a breadth first search from the start state reaches them,
+//trying the symbols in order. States that can't be reached are left out
+func renumberedWFA(wfa vectorWFA) vectorWFA {
+	numbers := map[wfaState]wfaState{wfa[0].startState: 0}
+	order := []wfaState{wfa[0].startState}
+	for i := 0; i < len(order); i++ {
+		for j := 0; j < wfa[0].symbols; j++ {
+			transition, ok := wfa[0].transitions[order[i]][symbol(j)]
+			if _, seen := numbers[transition.wfaState]; ok && !seen {
+				numbers[transition.wfaState] = wfaState(len(order))
+				order = append(order, transition.wfaState)
+			}
+		}
+	}
+	return quotientVectorWFA(wfa, numbers, len(order))
+}
+
+//the wfa with unreachable states removed, then the weights pushed and bisimilar states merged until nothing changes anymore,
+//with its states renumbered. Every word has the same weight as in the given wfa up to the potential of the state it ends in
+func minimizeWFA(wfa vectorWFA) vectorWFA {
+	classes, count := reachableStates(wfa)
+	wfa = pushWeights(quotientVectorWFA(wfa, classes, count))
+	for {
+		classes, count := bisimulationClasses(wfa)
+		if count == wfa[0].states {
+			return renumberedWFA(wfa)
+		}
+		wfa = pushWeights(quotientVectorWFA(wfa, classes, count))
+	}
+}
+
+//the same string for wfa that only differ in the numbering of their states or by a potential
+func canonicalForm(wfa vectorWFA) string {
+	return renumberedWFA(pushWeights(wfa)).String()
+}
+
+//the same string for wfa whose minimal forms only differ in the numbering of their states or by a potential
+func minimalForm(wfa vectorWFA) string {
+	return minimizeWFA(wfa).String()
+}
+
+//the wfa with the weights of one coordinate negated
+func negatedCoordinate(wfa vectorWFA, k int) vectorWFA {
+	result := copyVectorWFA(wfa)
+	for _, transitions := range result[k].transitions {
+		for symbol, transition := range transitions {
+			transitions[symbol] = wfaTransition{transition.wfaState, reduce(-transition.weight, result[k].modulus)}
+		}
+	}
+	return result
+}
+
+//the same string for wfa pairs that only differ in the numbering of their states, by potentials or by the sign of
+//a coordinate in both wfa. Negating a coordinate negates its weight sums, which mirrors the accept set
+func canonicalPair(leftWFA, rightWFA vectorWFA) string {
+	result := ""
+	for k := range leftWFA {
+		form := canonicalForm(vectorWFA{leftWFA[k]}) + "|" + canonicalForm(vectorWFA{rightWFA[k]})
+		mirrored := canonicalForm(negatedCoordinate(leftWFA, k)[k:k+1]) + "|" + canonicalForm(negatedCoordinate(rightWFA, k)[k:k+1])
+		if mirrored < form {
+			form = mirrored
+		}
+		result += form + ";"
+	}
+	return result
+}
+
+//the weighted wfa pairs the search already tried, by their canonical pair and the position in the search.
+//pairs with the same canonical pair have the same special sets and accept sets up to a shift or mirroring of the bounds
+//of each config, so only the first of them needs to be tried. All methods treat nil like a search that tries every pair
+type candidateSet struct {
+	seen set[string]
+}
+
+//whether the pair wasn't tried before at this position of the search. Afterwards it counts as tried
+func (c *candidateSet) isNew(leftWFA, rightWFA vectorWFA, position ...int) bool {
+	if c == nil {
+		return true
+	}
+	key := fmt.Sprint(canonicalPair(leftWFA, rightWFA), position)
+	if c.seen.contains(key) {
+		return false
+	}
+	c.seen.add(key)
+	return true
+}
+
+//prints the TM and the minimal forms of both wfa of each full certificate from the input on a line, so that sorting
+//the output brings certificates with the same wfa up to numbering, potentials and bisimilar states together
+func runCanonical(input *bufio.Scanner) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, _, _, _, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		fmt.Println(tm, minimalForm(leftWFA), minimalForm(rightWFA))
+	}
+}
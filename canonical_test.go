@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+func TestBisimulationClasses(t *testing.T) {
+	//states 2 and 3 both lead into the dead state 1, on 0 with weight 1
+	wfa, _ := parseVectorWFA("2,0;3,0_1,0;1,0_1,1;1,0_1,1;1,0")
+	classes, count := bisimulationClasses(wfa)
+	if count != 3 || classes[2] != classes[3] || classes[0] == classes[2] || classes[1] == classes[2] {
+		t.Error(classes, count)
+	}
+	//without weights every state would be bisimilar, but the dead state rejects every word
+	unweighted, _ := parseVectorWFA("0,0;2,0_1,0;1,0_2,0;2,0")
+	classes, count = bisimulationClasses(unweighted)
+	if count != 2 || classes[0] != classes[2] || classes[0] == classes[1] {
+		t.Error(classes, count)
+	}
+}
+
+func TestCanonicalForm(t *testing.T) {
+	wfa, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	//the same wfa with states 2 and 3 swapped and reweighted by the potential -1 of the new state 3
+	reweighted, _ := parseVectorWFA("0,0;3,0_1,0;1,0_1,0;3,1_2,0;3,0")
+	if canonicalForm(wfa) != canonicalForm(reweighted) {
+		t.Error(canonicalForm(wfa), canonicalForm(reweighted))
+	}
+	if renumberedWFA(wfa).String() == renumberedWFA(reweighted).String() {
+		t.Fail()
+	}
+	//a weight on a cycle can't be moved by a potential
+	cycle, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,0;2,1_1,0;2,0")
+	if canonicalForm(wfa) == canonicalForm(cycle) {
+		t.Fail()
+	}
+	//modular weights are pushed modulo their modulus: state 3 gets the potential 4 = 1 and the -1 of its transition back
+	//to state 2 with potential 2 becomes 2
+	modular, _ := parseVectorWFA("0,0;2,2_1,0;1,0_3,2;2,1_1,0;2,0%3")
+	if pushed := pushWeights(modular); pushed[0].transitions[0][1].weight != 0 || pushed[0].transitions[2][0].weight != 0 || pushed[0].transitions[3][1].weight != 2 {
+		t.Error(pushed)
+	}
+}
+
+func TestMinimizeWFA(t *testing.T) {
+	wfa, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	//state 4 is a copy of state 2 with its weights shifted by a potential and state 5 can't be reached
+	bloated, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;4,1_1,0;2,0_3,0;2,-1_5,0;5,0")
+	minimized := minimizeWFA(bloated)
+	if minimized[0].states != 4 || minimalForm(bloated) != minimalForm(wfa) {
+		t.Error(minimized, minimalForm(wfa))
+	}
+}
+
+func TestCandidateSet(t *testing.T) {
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	renumbered, _ := parseVectorWFA("0,0;3,0_1,0;1,0_1,0;3,0_2,1;3,0")
+	candidates := &candidateSet{set[string]{}}
+	if !candidates.isNew(leftWFA, rightWFA, 1) || candidates.isNew(renumbered, rightWFA, 1) || !candidates.isNew(leftWFA, rightWFA, 2) {
+		t.Fail()
+	}
+	if !(*candidateSet)(nil).isNew(leftWFA, rightWFA, 1) {
+		t.Fail()
+	}
+}
+
+func TestCanonicalPair(t *testing.T) {
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	mirroredLeft, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,-1;2,0_1,0;2,0")
+	mirroredRight, _ := parseVectorWFA("0,0;1,0_0,1;1,0")
+	if canonicalPair(leftWFA, rightWFA) != canonicalPair(mirroredLeft, mirroredRight) {
+		t.Fail()
+	}
+	//only mirroring one side changes the weight sums
+	if canonicalPair(leftWFA, rightWFA) == canonicalPair(mirroredLeft, rightWFA) {
+		t.Fail()
+	}
+}
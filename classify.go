@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//a TM config with the visited part of the tape. Cells outside of it are blank
+type tapeConfiguration struct {
+	state     tmState
+	leftHalf  []symbol
+	head      symbol
+	rightHalf []symbol
+}
+
+//"1011[B0]0110": the head is in state B on a 0
+func parseTapeConfiguration(s string, tm turingMachine) (tape tapeConfiguration, err error) {
+	defer func() {
+		if recover() != nil {
+			err = errorString("Couldn't parse tape configuration: \"" + s + "\"")
+		}
+	}()
+
+	start := strings.Index(s, "[")
+	end := strings.Index(s, "]")
+	if start < 0 || end != start+3 {
+		panic("")
+	}
+	tape.state = tmState(s[start+1] - 'A')
+	if int(tape.state) < 0 || int(tape.state) >= tm.states {
+		panic("")
+	}
+	parseSymbols := func(s string) []symbol {
+		result := []symbol{}
+		for i := range s {
+			newSymbol := symbol(s[i] - '0')
+			if newSymbol < 0 || int(newSymbol) >= tm.symbols {
+				panic("")
+			}
+			result = append(result, newSymbol)
+		}
+		return result
+	}
+	tape.leftHalf = parseSymbols(s[:start])
+	tape.head = parseSymbols(s[start+2 : start+3])[0]
+	tape.rightHalf = parseSymbols(s[end+1:])
+	return
+}
+
+func (tape tapeConfiguration) String() string {
+	result := ""
+	for _, symbol := range tape.leftHalf {
+		result += fmt.Sprint(symbol)
+	}
+	result += fmt.Sprintf("[%v%v]", tape.state, tape.head)
+	for _, symbol := range tape.rightHalf {
+		result += fmt.Sprint(symbol)
+	}
+	return result
+}
+
+//the config and weight sum of the tape as the wfa see it.
+//the left wfa reads its half from the left, the right wfa reads its half from the right
+func (tape tapeConfiguration) wfaConfig(leftWFA, rightWFA vectorWFA) (config, weightVector) {
+	weights := make(weightVector, len(leftWFA))
+	leftState := runVectorWFA(leftWFA, tape.leftHalf, weights)
+	reversed := []symbol{}
+	for i := len(tape.rightHalf) - 1; i >= 0; i-- {
+		reversed = append(reversed, tape.rightHalf[i])
+	}
+	rightState := runVectorWFA(rightWFA, reversed, weights)
+	return config{tape.state, tape.head, leftState, rightState}, weights
+}
+
+//runs the wfa over the symbols and adds the weights to the weight sum. Returns the reached state
+func runVectorWFA(wfa vectorWFA, symbols []symbol, weights weightVector) wfaState {
+	state := wfa[0].startState
+	for _, symbol := range symbols {
+		for i, coordinate := range wfa {
+			weights[i] = reduce(weights[i]+coordinate.transitions[state][symbol].weight, coordinate.modulus)
+			check(weights[i])
+		}
+		state = wfa[0].transitions[state][symbol].wfaState
+	}
+	return state
+}
+
+//where a tape configuration ends up in the language of a certificate
+type classification struct {
+	config  config
+	weights weightVector
+	//the accept set entry of the config, if there is one
+	polyhedron  polyhedron
+	inAcceptSet bool
+	accepted    bool
+}
+
+func classifyTape(leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet, tape tapeConfiguration) classification {
+	config, weights := tape.wfaConfig(leftWFA, rightWFA)
+	polyhedron, ok := acceptSet[config]
+	return classification{
+		config:      config,
+		weights:     weights,
+		polyhedron:  polyhedron,
+		inAcceptSet: ok,
+		accepted:    ok && polyhedronContains(polyhedron, weights),
+	}
+}
+
+func (c classification) String() string {
+	weights := []string{}
+	for _, weight := range c.weights {
+		weights = append(weights, fmt.Sprint(weight))
+	}
+	result := fmt.Sprintf("left state %v, right state %v, weights %v\n", c.config.leftState, c.config.rightState, strings.Join(weights, ","))
+	if c.inAcceptSet {
+		result += fmt.Sprintf("accept set entry %v,%v\n", c.config, c.polyhedron)
+	} else {
+		result += fmt.Sprintf("no accept set entry for %v\n", c.config)
+	}
+	if c.accepted {
+		return result + "accepted"
+	}
+	return result + "rejected"
+}
+
+//classifies the tape configuration with each full certificate from the input
+func runClassify(input *bufio.Scanner, tapeString string) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		//the wfa of a certificate that doesn't verify might not even have transitions for every symbol
+		if !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+			fmt.Fprintln(os.Stderr, errorString("Certificate doesn't verify: \""+tm.String()+"\""))
+			continue
+		}
+		tape, err := parseTapeConfiguration(tapeString, tm)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		fmt.Println(tm, tapeString)
+		fmt.Println(classifyTape(leftWFA, rightWFA, acceptSet, tape))
+	}
+}
+
+//the tape configurations with up to size non-blank cells on each side of the head that a certificate accepts,
+//compared to the ones the TM reaches within a number of steps
+type languageEnumeration struct {
+	size, steps int
+	//number of enumerated configurations
+	total    int
+	accepted []tapeConfiguration
+	reached  set[string]
+	//reached configurations the certificate doesn't accept, which a verified certificate can't have.
+	//only verified certificates are enumerated, so any of them is a soundness bug
+	escaped []tapeConfiguration
+}
+
+//all strings of the given length over the symbols
+func symbolStrings(symbols, length int) [][]symbol {
+	result := [][]symbol{{}}
+	for i := 0; i < length; i++ {
+		longer := [][]symbol{}
+		for _, s := range result {
+			for j := 0; j < symbols; j++ {
+				longer = append(longer, append(append([]symbol{}, s...), symbol(j)))
+			}
+		}
+		result = longer
+	}
+	return result
+}
+
+//every half of the tape is padded with blanks to the same size, which the leading blank invariant lets the wfa ignore
+func enumerateLanguage(tm turingMachine, leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet, size, steps int) languageEnumeration {
+	result := languageEnumeration{size: size, steps: steps, reached: set[string]{}}
+	halves := symbolStrings(tm.symbols, size)
+	for state := 0; state < tm.states; state++ {
+		for head := 0; head < tm.symbols; head++ {
+			for _, leftHalf := range halves {
+				for _, rightHalf := range halves {
+					tape := tapeConfiguration{tmState(state), leftHalf, symbol(head), rightHalf}
+					result.total += 1
+					if classifyTape(leftWFA, rightWFA, acceptSet, tape).accepted {
+						result.accepted = append(result.accepted, tape)
+					}
+				}
+			}
+		}
+	}
+	s := newSimulation(tm, steps+size)
+	for {
+		if tape, ok := s.window(size); ok && !result.reached.contains(tape.String()) {
+			result.reached.add(tape.String())
+			if !classifyTape(leftWFA, rightWFA, acceptSet, tape).accepted {
+				result.escaped = append(result.escaped, tape)
+			}
+		}
+		if s.steps >= steps {
+			break
+		}
+		s.step()
+		if s.halted {
+			break
+		}
+	}
+	return result
+}
+
+//the config of the simulation with size cells on each side of the head, if all non-blank cells are among them
+func (s *simulation) window(size int) (tapeConfiguration, bool) {
+	for position := s.leftmost; position <= s.rightmost; position++ {
+		if s.tape[position] != TMSTARTSYMBOL && (position < s.head-size || position > s.head+size) {
+			return tapeConfiguration{}, false
+		}
+	}
+	//the tape has size spare cells beyond the furthest the head can get
+	return tapeConfiguration{
+		state:     s.state,
+		leftHalf:  append([]symbol{}, s.tape[s.head-size:s.head]...),
+		head:      s.tape[s.head],
+		rightHalf: append([]symbol{}, s.tape[s.head+1:s.head+size+1]...),
+	}, true
+}
+
+//the accepted configurations, each marked as reached or not, followed by the escaped ones and the over-approximation ratio
+func (e languageEnumeration) String() string {
+	result := ""
+	for _, tape := range e.accepted {
+		if e.reached.contains(tape.String()) {
+			result += fmt.Sprintln(tape, "reached")
+		} else {
+			result += fmt.Sprintln(tape, "unreached")
+		}
+	}
+	for _, tape := range e.escaped {
+		result += fmt.Sprintln(tape, "escaped")
+	}
+	result += fmt.Sprintf("accepted %v of %v configurations with up to %v cells per side, reached %v in %v steps", len(e.accepted), e.total, e.size, len(e.reached), e.steps)
+	//without reached configurations there is nothing to compare the accepted ones to
+	if len(e.reached) == 0 {
+		return result
+	}
+	return result + fmt.Sprintf(", over-approximation ratio %.2f", float64(len(e.accepted))/float64(len(e.reached)))
+}
+
+//enumerates the language of each full certificate from the input
+func runEnumerate(input *bufio.Scanner, size, steps int) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		if !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+			fmt.Fprintln(os.Stderr, errorString("Certificate doesn't verify: \""+tm.String()+"\""))
+			continue
+		}
+		fmt.Println(tm)
+		fmt.Println(enumerateLanguage(tm, leftWFA, rightWFA, acceptSet, size, steps))
+	}
+}
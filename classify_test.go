@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTapeConfiguration(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	tape, err := parseTapeConfiguration("1011[B0]0110", tm)
+	expected := tapeConfiguration{1, []symbol{1, 0, 1, 1}, 0, []symbol{0, 1, 1, 0}}
+	if err != nil || !reflect.DeepEqual(tape, expected) {
+		t.Error(tape, err)
+	}
+	for _, invalid := range []string{"1011B00110", "10[B]0", "1[F0]1", "1[B2]1", "12[B0]"} {
+		if _, err := parseTapeConfiguration(invalid, tm); err == nil {
+			t.Error(invalid)
+		}
+	}
+}
+
+func TestClassifyTape(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	//scalar accept sets are written like vector accept sets with a single coordinate
+	acceptSet, _ := parseVectorAcceptSet(findAcceptSet(tm, leftWFA[0], rightWFA[0], deriveSpecialSets(leftWFA[0]), deriveSpecialSets(rightWFA[0]), searchOptions{}).String())
+	for tapeString, expected := range map[string]classification{
+		"[A0]":         {config: config{0, 0, 0, 0}, weights: weightVector{0}, inAcceptSet: true, accepted: true},
+		"1011[B0]0110": {config: config{1, 0, 2, 0}, weights: weightVector{0}, inAcceptSet: true, accepted: true},
+		"1[C0]11":      {config: config{2, 0, 2, 1}, weights: weightVector{0}, inAcceptSet: true, accepted: false},
+	} {
+		tape, _ := parseTapeConfiguration(tapeString, tm)
+		result := classifyTape(leftWFA, rightWFA, acceptSet, tape)
+		if result.config != expected.config || !reflect.DeepEqual(result.weights, expected.weights) ||
+			result.inAcceptSet != expected.inAcceptSet || result.accepted != expected.accepted {
+			t.Error(tapeString, result)
+		}
+	}
+}
+
+func TestEnumerateLanguage(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	acceptSet, _ := parseVectorAcceptSet(findAcceptSet(tm, leftWFA[0], rightWFA[0], deriveSpecialSets(leftWFA[0]), deriveSpecialSets(rightWFA[0]), searchOptions{}).String())
+	enumeration := enumerateLanguage(tm, leftWFA, rightWFA, acceptSet, 2, 1000)
+	if enumeration.total != 5*2*16 || len(enumeration.escaped) != 0 {
+		t.Fail()
+	}
+	//the blank tape and the first steps fit into 2 cells per side
+	for _, tapeString := range []string{"00[A0]00", "01[B0]00", "10[C0]00"} {
+		if !enumeration.reached.contains(tapeString) {
+			t.Error(tapeString)
+		}
+	}
+	accepted := set[string]{}
+	for _, tape := range enumeration.accepted {
+		accepted.add(tape.String())
+	}
+	for tapeString := range enumeration.reached {
+		if !accepted.contains(tapeString) {
+			t.Error(tapeString)
+		}
+	}
+	if len(accepted) <= len(enumeration.reached) {
+		t.Fail()
+	}
+	if !strings.HasSuffix(enumeration.String(), fmt.Sprintf(", over-approximation ratio %.2f", float64(len(accepted))/float64(len(enumeration.reached)))) {
+		t.Error(enumeration)
+	}
+	//nothing reached leaves out the ratio instead of dividing by 0
+	empty := languageEnumeration{size: 2, steps: 0, total: 160, reached: set[string]{}}
+	if empty.String() != "accepted 0 of 160 configurations with up to 2 cells per side, reached 0 in 0 steps" {
+		t.Error(empty)
+	}
+}
+
+func TestVerifyFullCertificate(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+	rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+	acceptSet, _ := parseVectorAcceptSet(findAcceptSet(tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], searchOptions{}).String())
+	if !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+		t.Fail()
+	}
+	//a right wfa for a single symbol would read every 1 of the tape as state 0
+	shortRightWFA, _ := parseVectorWFA("0,0_0,-1")
+	if verifyFullCertificate(tm, leftWFA, shortRightWFA, leftSpecialSets, deriveVectorSpecialSets(shortRightWFA), acceptSet) {
+		t.Fail()
+	}
+}
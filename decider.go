@@ -6,34 +6,91 @@ import (
 )
 
 func deriveSpecialSets(wfa dwfa) specialSets {
-	possibleNegative := set[wfaState]{}
-	possiblePositive := set[wfaState]{}
-	for _, tmp := range wfa.transitions {
-		for _, transition := range tmp {
-			if transition.weight < 0 {
-				possibleNegative.add(transition.wfaState)
-			}
-			if transition.weight > 0 {
-				possiblePositive.add(transition.wfaState)
-			}
+	if wfa.modulus > 0 {
+		return specialSets{residues: reachableResidues(wfa)}
+	}
+	reachable := set[wfaState]{wfa.startState: {}}
+	completeClosure(reachable, wfa)
+	intervals := map[wfaState]bounds{}
+	for state := range reachable {
+		intervals[state] = bounds{}
+	}
+	for _, bound := range []boundType{LOWER, UPPER} {
+		for state, value := range extremePathWeights(wfa, reachable, bound) {
+			intervals[state][bound] = value
 		}
 	}
-	completeClosure(possibleNegative, wfa)
-	completeClosure(possiblePositive, wfa)
+	return specialSets{intervals: intervals}
+}
 
-	specialSets := specialSets{
-		nonNegative: set[wfaState]{},
-		nonPositive: set[wfaState]{},
+//bellman-ford for the shortest (LOWER) or longest (UPPER) paths from the start state.
+//states that can be reached through a negative (LOWER) or positive (UPPER) cycle are left out as they are unbounded.
+func extremePathWeights(wfa dwfa, reachable set[wfaState], bound boundType) map[wfaState]weight {
+	better := func(a, b weight) bool {
+		if bound == LOWER {
+			return a < b
+		}
+		return a > b
+	}
+	distance := map[wfaState]weight{wfa.startState: 0}
+	relax := func() set[wfaState] {
+		changed := set[wfaState]{}
+		for i := 0; i < wfa.states; i++ {
+			fromDistance, ok := distance[wfaState(i)]
+			if !ok {
+				continue
+			}
+			for j := 0; j < wfa.symbols; j++ {
+				transition, ok := wfa.transitions[wfaState(i)][symbol(j)]
+				if !ok {
+					continue
+				}
+				newDistance := fromDistance + transition.weight
+				check(newDistance)
+				if oldDistance, ok := distance[transition.wfaState]; !ok || better(newDistance, oldDistance) {
+					distance[transition.wfaState] = newDistance
+					changed.add(transition.wfaState)
+				}
+			}
+		}
+		return changed
 	}
-	for i := 0; i < wfa.states; i++ {
-		if !possibleNegative.contains(wfaState(i)) {
-			specialSets.nonNegative.add(wfaState(i))
+	for i := 0; i < len(reachable); i++ {
+		if len(relax()) == 0 {
+			return distance
 		}
-		if !possiblePositive.contains(wfaState(i)) {
-			specialSets.nonPositive.add(wfaState(i))
+	}
+	unbounded := relax()
+	completeClosure(unbounded, wfa)
+	for state := range unbounded {
+		delete(distance, state)
+	}
+	return distance
+}
+
+//search through all combinations of state and residue that can be reached from the start state
+func reachableResidues(wfa dwfa) map[wfaState]set[weight] {
+	type stateWithResidue struct {
+		wfaState
+		weight
+	}
+	result := map[wfaState]set[weight]{wfa.startState: {0: {}}}
+	todo := []stateWithResidue{{wfa.startState, 0}}
+	for len(todo) > 0 {
+		current := todo[0]
+		todo = todo[1:]
+		for _, transition := range wfa.transitions[current.wfaState] {
+			next := stateWithResidue{transition.wfaState, mod(current.weight+transition.weight, wfa.modulus)}
+			if _, ok := result[next.wfaState]; !ok {
+				result[next.wfaState] = set[weight]{}
+			}
+			if !result[next.wfaState].contains(next.weight) {
+				result[next.wfaState].add(next.weight)
+				todo = append(todo, next)
+			}
 		}
 	}
-	return specialSets
+	return result
 }
 
 func completeClosure(set set[wfaState], wfa dwfa) {
@@ -54,9 +111,20 @@ func completeClosure(set set[wfaState], wfa dwfa) {
 	}
 }
 
-func findAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets) acceptSet {
+//with congruences the accept set also keeps track of the largest modulus all weight sums of a config agree in.
+//each config accepts a union of up to options.intervals disjoint intervals
+func findAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, options searchOptions) acceptSet {
+	return traceAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, options, nil)
+}
+
+//findAcceptSet that records each change of the accept set in the trace, unless it is nil
+func traceAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, options searchOptions, trace *derivationTrace) acceptSet {
 	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}
 	initialBounds := bounds{LOWER: 0, UPPER: 0}
+	if options.congruences {
+		initialBounds[MODULUS] = 0
+		initialBounds[RESIDUE] = 0
+	}
 	todo := []config{initialConfig}
 	result := acceptSet{initialConfig: initialBounds}
 
@@ -67,6 +135,9 @@ func findAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, ri
 
 		nextConfigs := nextConfigsWithWeightChange(currentConfig, tm, leftWFA, rightWFA)
 		if len(nextConfigs) == 0 {
+			if trace != nil {
+				trace.halting = &currentConfig
+			}
 			return acceptSet{}
 		}
 		//sort to make this AcceptSetFinder deterministic.
@@ -76,70 +147,67 @@ func findAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, ri
 			return fmt.Sprint(nextConfigs[i].config) < fmt.Sprint(nextConfigs[j].config)
 		})
 		for _, nextConfigWithWeightChange := range nextConfigs {
-
-			if changeAcceptSetToContainNextConfigWithWeightChange(nextConfigWithWeightChange, currentBounds, leftSpecialSets, rightSpecialSets, result) {
+			var oldBounds, predecessorBounds bounds
+			if trace != nil {
+				oldBounds = copyBounds(result[nextConfigWithWeightChange.config])
+				//the join changes currentBounds itself if the config leads to itself
+				predecessorBounds = copyBounds(currentBounds)
+			}
+			if changeAcceptSetToContainNextConfigWithWeightChange(nextConfigWithWeightChange, currentBounds, leftSpecialSets, rightSpecialSets, result, options.intervals) {
 				todo = append(todo, nextConfigWithWeightChange.config)
+				if trace != nil {
+					trace.record(currentConfig, predecessorBounds, nextConfigWithWeightChange, oldBounds, result, leftSpecialSets, rightSpecialSets)
+				}
 			}
 		}
 
 	}
+	//weight sums that are known exactly are already described by the interval
+	for _, bounds := range result {
+		if modulus, ok := bounds[MODULUS]; ok && modulus == 0 {
+			delete(bounds, MODULUS)
+			delete(bounds, RESIDUE)
+		}
+	}
 	return result
 }
 
-func changeAcceptSetToContainNextConfigWithWeightChange(nextConfigWithWeightChange configWithWeight, bounds bounds, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) bool {
+func changeAcceptSetToContainNextConfigWithWeightChange(nextConfigWithWeightChange configWithWeight, bounds bounds, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet, maxIntervals int) bool {
 	nextConfig := nextConfigWithWeightChange.config
-	lowerbound, lowerExists := bounds[LOWER]
-	upperbound, upperExists := bounds[UPPER]
-
-	//adjust bounds according to the change
-	if lowerExists {
-		lowerbound += nextConfigWithWeightChange.weight
-	}
-	if upperExists {
-		upperbound += nextConfigWithWeightChange.weight
-	}
-
-	hardLower := false
-	//adjust bounds according to the special sets
-	if leftSpecialSets.nonNegative.contains(nextConfig.leftState) && rightSpecialSets.nonNegative.contains(nextConfig.rightState) {
-		hardLower = true
-		if !lowerExists || lowerbound < 0 {
-			lowerExists = true
-			lowerbound = 0
-		}
-	}
-	hardUpper := false
-	if leftSpecialSets.nonPositive.contains(nextConfig.leftState) && rightSpecialSets.nonPositive.contains(nextConfig.rightState) {
-		hardUpper = true
-		if !upperExists || upperbound > 0 {
-			upperExists = true
-			upperbound = 0
-		}
-	}
 
-	nextBounds := map[boundType]weight{}
-	if lowerExists {
-		nextBounds[LOWER] = lowerbound
-	}
-	if upperExists {
-		nextBounds[UPPER] = upperbound
+	//adjust bounds according to the weights the wfa states can actually have
+	hardBounds, reachable := sumOfStateBounds(leftSpecialSets, rightSpecialSets, nextConfig.leftState, nextConfig.rightState)
+	if !reachable {
+		return false
 	}
-
-	if upperExists && lowerExists && upperbound < lowerbound {
+	nextBounds, nonEmpty := clipBounds(shiftBounds(bounds, nextConfigWithWeightChange.weight), hardBounds)
+	if !nonEmpty {
 		return false
 	}
-	return ChangeAcceptSetToCountainConfigBounds(acceptSet, nextConfig, nextBounds, hardLower, hardUpper)
+	return ChangeAcceptSetToCountainConfigBounds(acceptSet, nextConfig, nextBounds, hardBounds, maxIntervals)
 }
 
 const MAXFINITEINTERVALL = 1000
 
-func ChangeAcceptSetToCountainConfigBounds(acceptSet acceptSet, nextConfig config, nextBounds map[boundType]weight, hardLower, hardUpper bool) bool {
+//the number of disjoint intervals short certificates can be expanded to
+const SHORTCERTINTERVALS = 4
+
+func ChangeAcceptSetToCountainConfigBounds(acceptSet acceptSet, nextConfig config, nextBounds map[boundType]weight, hardBounds bounds, maxIntervals int) bool {
 	acceptBounds, ok := acceptSet[nextConfig]
 	if !ok {
 		acceptSet[nextConfig] = nextBounds
 		return true
 	}
+	return joinBounds(acceptBounds, nextBounds, hardBounds, maxIntervals)
+}
+
+//widens the accepted bounds in place to include the next bounds.
+//bounds that would result in an interval longer than MAXFINITEINTERVALL are dropped down to the hard bounds instead.
+//the result is a union of at most maxIntervals disjoint intervals
+func joinBounds(acceptBounds, nextBounds, hardBounds bounds, maxIntervals int) bool {
 	change := false
+	oldGaps := acceptBounds.gaps()
+	newGaps := joinGaps(acceptBounds, nextBounds, maxIntervals)
 	acceptedLower, acceptedLowerExists := acceptBounds[LOWER]
 	nextLower, nextLowerExists := nextBounds[LOWER]
 	acceptedUpper, acceptedUpperExists := acceptBounds[UPPER]
@@ -148,32 +216,148 @@ func ChangeAcceptSetToCountainConfigBounds(acceptSet acceptSet, nextConfig confi
 	if acceptedLowerExists && (!nextLowerExists || acceptedLower > nextLower) {
 		change = true
 		if !acceptedUpperExists || !nextLowerExists || acceptedUpper-nextLower > MAXFINITEINTERVALL {
-			delete(acceptSet[nextConfig], LOWER)
-			if hardLower {
-				acceptSet[nextConfig][LOWER] = 0
+			delete(acceptBounds, LOWER)
+			if hardLower, ok := hardBounds[LOWER]; ok {
+				acceptBounds[LOWER] = hardLower
 			}
 		} else {
-			acceptSet[nextConfig][LOWER] = nextLower
+			acceptBounds[LOWER] = nextLower
 		}
 	}
 
 	if acceptedUpperExists && (!nextUpperExists || acceptedUpper < nextUpper) {
 		change = true
 		if !acceptedLowerExists || !nextUpperExists || nextUpper-acceptedLower > MAXFINITEINTERVALL {
-			delete(acceptSet[nextConfig], UPPER)
-			if hardUpper {
-				acceptSet[nextConfig][UPPER] = 0
+			delete(acceptBounds, UPPER)
+			if hardUpper, ok := hardBounds[UPPER]; ok {
+				acceptBounds[UPPER] = hardUpper
 			}
 		} else {
-			acceptSet[nextConfig][UPPER] = nextUpper
+			acceptBounds[UPPER] = nextUpper
+		}
+	}
+
+	//the joined congruence is the largest modulus both agree in, a modulus of 1 is no restriction at all
+	if acceptedModulus, ok := acceptBounds[MODULUS]; ok {
+		newModulus := weight(1)
+		if nextModulus, ok := nextBounds[MODULUS]; ok {
+			newModulus = gcd(gcd(acceptedModulus, nextModulus), acceptBounds[RESIDUE]-nextBounds[RESIDUE])
+		}
+		if newModulus != acceptedModulus {
+			change = true
+			if newModulus == 1 {
+				delete(acceptBounds, MODULUS)
+				delete(acceptBounds, RESIDUE)
+			} else {
+				acceptBounds[MODULUS] = newModulus
+				acceptBounds[RESIDUE] = reduce(acceptBounds[RESIDUE], newModulus)
+			}
+		}
+	}
+
+	acceptBounds.setGaps(newGaps)
+	if len(newGaps) != len(oldGaps) {
+		change = true
+	}
+	for i := 0; i < len(newGaps) && i < len(oldGaps); i++ {
+		if newGaps[i] != oldGaps[i] {
+			change = true
 		}
 	}
 	return change
 }
 
+//the gaps of the union of both bounds. If there are more than maxIntervals intervals
+//the smallest gaps get filled up, so the nearest intervals are merged.
+func joinGaps(acceptBounds, nextBounds bounds, maxIntervals int) []gap {
+	if maxIntervals <= 1 {
+		return []gap{}
+	}
+	intervals := append(acceptBounds.intervals(), nextBounds.intervals()...)
+	sort.Slice(intervals, func(i, j int) bool {
+		lowerI, existsI := intervals[i][LOWER]
+		lowerJ, existsJ := intervals[j][LOWER]
+		return !existsI && existsJ || existsI && existsJ && lowerI < lowerJ
+	})
+	result := []gap{}
+	current := intervals[0]
+	for _, interval := range intervals[1:] {
+		currentUpper, currentUpperExists := current[UPPER]
+		if !currentUpperExists {
+			break
+		}
+		if lowerbound, ok := interval[LOWER]; ok && lowerbound > currentUpper+1 {
+			result = append(result, gap{currentUpper + 1, lowerbound - 1})
+			current = interval
+			continue
+		}
+		if upperbound, ok := interval[UPPER]; !ok || upperbound > currentUpper {
+			current = interval
+		}
+	}
+	for len(result) > 0 && len(result) >= maxIntervals {
+		smallest := 0
+		for i, gap := range result {
+			if gap.upper-gap.lower < result[smallest].upper-result[smallest].lower {
+				smallest = i
+			}
+		}
+		result = append(result[:smallest], result[smallest+1:]...)
+	}
+	return result
+}
+
 //------------------------------------------------------------------------------------------------
 
-func MITMWFARdecider(tm turingMachine, maxTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode int) bool {
+type searchOptions struct {
+	//number of independent counters. Values below 2 use plain integer weights
+	dimensions int
+	//modulus of each counter, 0 for integer counters
+	moduli []weight
+	//track the weights of the left and right side separately instead of only their sum
+	splitSides bool
+	//also bound the sum and difference of every pair of integer counters
+	polyhedral bool
+	//let the accept set also restrict the weight sum to a residue class
+	congruences bool
+	//maximum number of disjoint intervals the accept set may accept per config. Values below 2 use a single interval
+	intervals int
+	//solve a linear program for the weights of each closed dwfa pair before trying the weight pairs
+	solveWeights bool
+	//add the memory to the closed dwfa pair before placing any weights instead of copying the weights into the memory
+	weightsAfterMemory bool
+	//the dfa both wfa are multiplied with after adding the memory, if any
+	auxiliary auxiliaryDFA
+	//the largest absolute value of a weight the scalar weight search places. 0 only places {1, -1} and {-1, 1},
+	//which is all the vector weight search of dimensions and moduli ever places
+	maxWeight int
+	//number of steps to simulate each solved TM for, checking that it never escapes the accept set
+	simulationSteps int
+	//report search statistics for each TM that isn't solved
+	explain bool
+	//the statistics of the current TM, if explain is set
+	statistics *searchStatistics
+	//keep searching after a proof and print up to this many distinct proofs of each TM
+	allProofs int
+	//the proofs of the current TM, if allProofs is set
+	proofs *proofCollection
+	//the weighted wfa pairs the search of the current TM already tried
+	candidates *candidateSet
+}
+
+//a copy of the options with fresh candidates, and statistics and proof collection if they are wanted, for the search of a single TM
+func (options searchOptions) forTM() searchOptions {
+	options.candidates = &candidateSet{set[string]{}}
+	if options.explain {
+		options.statistics = &searchStatistics{}
+	}
+	if options.allProofs > 0 {
+		options.proofs = &proofCollection{maxProofs: options.allProofs, proofs: map[string]proof{}}
+	}
+	return options
+}
+
+func MITMWFARdecider(tm turingMachine, maxTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
 	leftWFA := dwfa{
 		states:      2,
 		symbols:     tm.symbols,
@@ -199,16 +383,17 @@ func MITMWFARdecider(tm turingMachine, maxTransitions, maxStatesLeft, maxStatesR
 	}
 	leftWFA.transitions[0][0] = wfaTransition{0, 0}
 	rightWFA.transitions[0][0] = wfaTransition{0, 0}
-	return recursiveDecider(tm, leftWFA, rightWFA, 2, maxTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode)
+	return recursiveDecider(tm, leftWFA, rightWFA, 2, maxTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode)
 }
 
-func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitions, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode int) bool {
+func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitions, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
 	closed, breakingSide, breakingState, breakingSymbol := findClosure(tm, leftWFA, rightWFA)
 	if closed {
 		if currentTransitions != targetTransitions {
 			return false
 		}
-		return recursiveWeightAdder(tm, leftWFA, rightWFA, 0, maxWeightPairs, addedMemory, printMode)
+		options.statistics.closedPair()
+		return findWeights(tm, leftWFA, rightWFA, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode)
 	}
 	if currentTransitions >= targetTransitions {
 		return false
@@ -224,7 +409,7 @@ func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitio
 				newWFA.transitions[newState][symbol(i)] = wfaTransition{1, 0}
 			}
 			newWFA.transitions[breakingState][breakingSymbol] = wfaTransition{newState, 0}
-			if recursiveDecider(tm, newWFA, rightWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode) {
+			if recursiveDecider(tm, newWFA, rightWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
 				return true
 			}
 		}
@@ -234,7 +419,7 @@ func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitio
 			}
 			newWFA := copyWFA(leftWFA)
 			newWFA.transitions[breakingState][breakingSymbol] = wfaTransition{wfaState(i), 0}
-			if recursiveDecider(tm, newWFA, rightWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode) {
+			if recursiveDecider(tm, newWFA, rightWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
 				return true
 			}
 		}
@@ -248,7 +433,7 @@ func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitio
 				newWFA.transitions[newState][symbol(i)] = wfaTransition{1, 0}
 			}
 			newWFA.transitions[breakingState][breakingSymbol] = wfaTransition{newState, 0}
-			if recursiveDecider(tm, leftWFA, newWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode) {
+			if recursiveDecider(tm, leftWFA, newWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
 				return true
 			}
 		}
@@ -258,7 +443,7 @@ func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitio
 			}
 			newWFA := copyWFA(rightWFA)
 			newWFA.transitions[breakingState][breakingSymbol] = wfaTransition{wfaState(i), 0}
-			if recursiveDecider(tm, leftWFA, newWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemory, printMode) {
+			if recursiveDecider(tm, leftWFA, newWFA, currentTransitions+1, targetTransitions, maxStatesLeft, maxStatesRight, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
 				return true
 			}
 		}
@@ -266,6 +451,37 @@ func recursiveDecider(tm turingMachine, leftWFA, rightWFA dwfa, currentTransitio
 	return false
 }
 
+//tries to weight the closed base dfa pair in all the ways the options allow
+func findWeights(tm turingMachine, leftWFA, rightWFA dwfa, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
+	//a single weight pair rarely gives the same pair up to potentials twice, which doesn't pay for the canonical form of every pair
+	if maxWeightPairs <= 1 {
+		options.candidates = nil
+	}
+	if options.weightsAfterMemory {
+		for i := 0; i < addedMemoryLeft; i++ {
+			leftWFA = addWFAMemory(leftWFA)
+		}
+		for i := 0; i < addedMemoryRight; i++ {
+			rightWFA = addWFAMemory(rightWFA)
+		}
+		addedMemoryLeft, addedMemoryRight = 0, 0
+	}
+	if options.dimensions > 1 || len(options.moduli) > 0 || options.splitSides || options.polyhedral {
+		dimensions := options.dimensions
+		if dimensions < 1 {
+			dimensions = 1
+		}
+		if dimensions < len(options.moduli) {
+			dimensions = len(options.moduli)
+		}
+		return recursiveVectorWeightAdder(tm, newVectorWFA(leftWFA, dimensions, options.moduli), newVectorWFA(rightWFA, dimensions, options.moduli), 0, 0, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode)
+	}
+	if options.solveWeights && solveWeights(tm, leftWFA, rightWFA, addedMemoryLeft, addedMemoryRight, options, printMode) {
+		return true
+	}
+	return recursiveWeightAdder(tm, leftWFA, rightWFA, 0, 0, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode)
+}
+
 func findClosure(tm turingMachine, leftWFA, rightWFA dwfa) (bool, direction, wfaState, symbol) {
 	accept := set[config]{}
 	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}
@@ -293,48 +509,113 @@ func findClosure(tm turingMachine, leftWFA, rightWFA dwfa) (bool, direction, wfa
 	return true, L, 0, 0
 }
 
-func recursiveWeightAdder(tm turingMachine, leftWFA, rightWFA dwfa, currenWeightPairs, maxWeightPairs, addedMemory, printMode int) bool {
+//a transition a weight pair can be placed on
+type weightSlot struct {
+	wfaState
+	symbol
+}
 
-	tryLeftWFA := copyWFA(leftWFA)
-	tryRightWFA := copyWFA(rightWFA)
-	for i := 0; i < addedMemory; i++ {
-		tryLeftWFA = addWFAMemory(tryLeftWFA)
-		tryRightWFA = addWFAMemory(tryRightWFA)
+//the transitions of the wfa that can get a weight in order: all except those into the dead state and the loop
+//of the start state on the blank symbol, which has to keep weight 0
+func weightSlots(wfa dwfa) []weightSlot {
+	result := []weightSlot{}
+	for i := 0; i < wfa.states; i++ {
+		for j := 0; j < wfa.symbols; j++ {
+			transition, ok := wfa.transitions[wfaState(i)][symbol(j)]
+			if ok && transition.wfaState != 1 && !(i == 0 && j == 0) {
+				result = append(result, weightSlot{wfaState(i), symbol(j)})
+			}
+		}
 	}
-	leftSpecialSets := deriveSpecialSets(tryLeftWFA)
-	rightSpecialSets := deriveSpecialSets(tryRightWFA)
-	acceptSet := findAcceptSet(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets)
-	if len(acceptSet) > 0 && MITMWFARverifier(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode) {
-		return true
+	return result
+}
+
+//the weight pairs the search places: those with a positive left weight first and then their negations in the same order.
+//without a maximum weight these are {1, -1} and {-1, 1}. Otherwise all pairs up to the maximum are used, the pairs of opposite
+//signs before those of the same sign. If only a single pair is placed, pairs with a common divisor are left out, as scaling
+//every weight only scales the weight sums and the accept set with them. Several pairs can combine a multiple with other pairs
+//in ways the reduced pair can't within the same number of pairs, so then they are kept
+func weightPermutations(maxWeight, maxWeightPairs int) [][2]weight {
+	positive := [][2]weight{{1, -1}}
+	if maxWeight > 0 {
+		positive = [][2]weight{}
+		for _, sign := range []weight{-1, 1} {
+			for a := weight(1); a <= weight(maxWeight); a++ {
+				for b := weight(1); b <= weight(maxWeight); b++ {
+					if maxWeightPairs > 1 || gcd(a, b) == 1 {
+						positive = append(positive, [2]weight{a, sign * b})
+					}
+				}
+			}
+		}
+	}
+	result := append([][2]weight{}, positive...)
+	for _, weights := range positive {
+		result = append(result, [2]weight{-weights[0], -weights[1]})
+	}
+	return result
+}
+
+//whether some state of the wfa can be reached with a weight of the given sign, according to its special sets
+func canHaveSign(specialSets specialSets, sign weight) bool {
+	for _, stateBounds := range specialSets.intervals {
+		bound, bounded := stateBounds[LOWER]
+		if sign > 0 {
+			bound, bounded = stateBounds[UPPER]
+		}
+		if !bounded || bound*sign > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//places up to maxWeightPairs weight pairs on the closed pair and tries each result. A placement is a weight permutation,
+//a left slot and a right slot. The weights only depend on the multiset of placements, so the placements are only
+//made in increasing order starting at nextPlacement, with the permutation as the most significant part.
+//multisets of negated placements only are skipped as they are the mirror images of multisets with a positive left weight,
+//and pairs that are the same as one already tried up to numbering, potentials and mirroring aren't tried again.
+//a pair of the same sign only moves the weight sum one way, so it is only placed if the special sets show that
+//the weights placed so far can move it the other way. The unweighted pair can't, so a lone pair of the same sign is never tried
+func recursiveWeightAdder(tm turingMachine, leftWFA, rightWFA dwfa, currenWeightPairs, nextPlacement, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
+	if options.candidates.isNew(vectorWFA{leftWFA}, vectorWFA{rightWFA}, addedMemoryLeft, addedMemoryRight) {
+		tryLeftWFA := liftWFA(leftWFA, addedMemoryLeft, options)
+		tryRightWFA := liftWFA(rightWFA, addedMemoryRight, options)
+		leftSpecialSets := deriveSpecialSets(tryLeftWFA)
+		rightSpecialSets := deriveSpecialSets(tryRightWFA)
+		acceptSet := findAcceptSet(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, options)
+		if len(acceptSet) > 0 && MITMWFARverifier(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			return scalarSimulationCheck(tm, tryLeftWFA, tryRightWFA, acceptSet, options.simulationSteps) &&
+				options.proofs.add(printMode, tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+		}
+		options.statistics.rejectedAcceptSet(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
 	}
 	if currenWeightPairs >= maxWeightPairs {
 		return false
 	}
-	weightPermutations := [][2]weight{{1, -1}}
-	if currenWeightPairs > 0 {
-		weightPermutations = append(weightPermutations, [2]weight{-1, 1})
-	}
-	for _, weights := range weightPermutations {
-		for leftState, tmpLeft := range leftWFA.transitions {
-			for leftSymbol, leftTransition := range tmpLeft {
-				if leftTransition.wfaState == 1 || (leftState == 0 && leftSymbol == 0) {
-					continue
-				}
-				newLeftWFA := copyWFA(leftWFA)
-				newLeftWFA.transitions[leftState][leftSymbol] = wfaTransition{leftTransition.wfaState, leftTransition.weight + weights[0]}
-				for rightState, tmpRight := range rightWFA.transitions {
-					for rightSymbol, rightTransition := range tmpRight {
-						if rightTransition.wfaState == 1 || (rightState == 0 && rightSymbol == 0) {
-							continue
-						}
-						newRightWFA := copyWFA(rightWFA)
-						newRightWFA.transitions[rightState][rightSymbol] = wfaTransition{rightTransition.wfaState, rightTransition.weight + weights[1]}
-						if recursiveWeightAdder(tm, newLeftWFA, newRightWFA, currenWeightPairs+1, maxWeightPairs, addedMemory, printMode) {
-							return true
-						}
-					}
-				}
-			}
+	permutations := weightPermutations(options.maxWeight, maxWeightPairs)
+	if currenWeightPairs == 0 {
+		permutations = permutations[:len(permutations)/2]
+	}
+	leftSpecialSets := deriveSpecialSets(leftWFA)
+	rightSpecialSets := deriveSpecialSets(rightWFA)
+	leftSlots := weightSlots(leftWFA)
+	rightSlots := weightSlots(rightWFA)
+	for placement := nextPlacement; placement < len(permutations)*len(leftSlots)*len(rightSlots); placement++ {
+		weights := permutations[placement/(len(leftSlots)*len(rightSlots))]
+		if sign := weights[0]; sign*weights[1] > 0 && !canHaveSign(leftSpecialSets, -sign) && !canHaveSign(rightSpecialSets, -sign) {
+			continue
+		}
+		leftSlot := leftSlots[placement/len(rightSlots)%len(leftSlots)]
+		rightSlot := rightSlots[placement%len(rightSlots)]
+		newLeftWFA := copyWFA(leftWFA)
+		leftTransition := leftWFA.transitions[leftSlot.wfaState][leftSlot.symbol]
+		newLeftWFA.transitions[leftSlot.wfaState][leftSlot.symbol] = wfaTransition{leftTransition.wfaState, leftTransition.weight + weights[0]}
+		newRightWFA := copyWFA(rightWFA)
+		rightTransition := rightWFA.transitions[rightSlot.wfaState][rightSlot.symbol]
+		newRightWFA.transitions[rightSlot.wfaState][rightSlot.symbol] = wfaTransition{rightTransition.wfaState, rightTransition.weight + weights[1]}
+		if recursiveWeightAdder(tm, newLeftWFA, newRightWFA, currenWeightPairs+1, placement, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
+			return true
 		}
 	}
 	return false
@@ -360,6 +641,7 @@ func addWFAMemory(oldWFA dwfa) dwfa {
 		symbols:     oldWFA.symbols,
 		startState:  newStateNumbers[oldWFA.startState][TMSTARTSYMBOL],
 		transitions: map[wfaState]map[symbol]wfaTransition{},
+		modulus:     oldWFA.modulus,
 	}
 	for i := 0; i < newWFA.states; i++ {
 		newWFA.transitions[wfaState(i)] = map[symbol]wfaTransition{}
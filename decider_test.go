@@ -2,6 +2,7 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -22,8 +23,45 @@ func TestDeriveSpecialSets(t *testing.T) {
 		},
 	}
 	expectedSets := specialSets{
-		nonNegative: map[wfaState]struct{}{0: {}, 1: {}, 2: {}},
-		nonPositive: map[wfaState]struct{}{0: {}, 1: {}},
+		intervals: map[wfaState]bounds{
+			0: {LOWER: 0, UPPER: 0},
+			1: {LOWER: 0, UPPER: 0},
+			2: {LOWER: 0},
+			3: {},
+		},
+	}
+
+	specialSets := deriveSpecialSets(wfa)
+	if !reflect.DeepEqual(expectedSets, specialSets) {
+		t.Fail()
+	}
+}
+
+func TestDeriveSpecialSetsIntervals(t *testing.T) {
+	wfa := dwfa{
+		states:     5,
+		symbols:    2,
+		startState: 0,
+		transitions: map[wfaState]map[symbol]wfaTransition{
+			0: {0: {0, 0},
+				1: {1, -3}},
+			1: {0: {2, 2},
+				1: {3, 1}},
+			2: {0: {3, 0},
+				1: {2, 0}},
+			3: {0: {3, 0},
+				1: {3, 0}},
+			4: {0: {4, 1},
+				1: {0, 0}},
+		},
+	}
+	expectedSets := specialSets{
+		intervals: map[wfaState]bounds{
+			0: {LOWER: 0, UPPER: 0},
+			1: {LOWER: -3, UPPER: -3},
+			2: {LOWER: -1, UPPER: -1},
+			3: {LOWER: -2, UPPER: -1},
+		},
 	}
 
 	specialSets := deriveSpecialSets(wfa)
@@ -84,13 +122,68 @@ func TestFindAcceptSet(t *testing.T) {
 		{B, 1, 0, 0}: {LOWER: 0},
 		{B, 1, 0, 1}: {LOWER: 0},
 	}
-	result := findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets)
+	result := findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, searchOptions{})
 
 	if !reflect.DeepEqual(expectedResult, result) {
 		t.Fail()
 	}
 }
 
+func TestJoinBoundsCongruence(t *testing.T) {
+	acceptBounds := bounds{LOWER: 0, UPPER: 0, MODULUS: 0, RESIDUE: 0}
+	if !joinBounds(acceptBounds, bounds{LOWER: 4, UPPER: 4, MODULUS: 0, RESIDUE: 4}, bounds{}, 1) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 4, MODULUS: 4, RESIDUE: 0}) {
+		t.Fail()
+	}
+	if !joinBounds(acceptBounds, bounds{LOWER: 2, UPPER: 2, MODULUS: 0, RESIDUE: 2}, bounds{}, 1) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 4, MODULUS: 2, RESIDUE: 0}) {
+		t.Fail()
+	}
+	if joinBounds(acceptBounds, bounds{LOWER: 2, UPPER: 4, MODULUS: 2, RESIDUE: 0}, bounds{}, 1) {
+		t.Fail()
+	}
+	joinBounds(acceptBounds, bounds{LOWER: 3, UPPER: 3, MODULUS: 0, RESIDUE: 3}, bounds{}, 1)
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 4}) {
+		t.Fail()
+	}
+}
+
+func TestJoinBoundsIntervals(t *testing.T) {
+	acceptBounds := bounds{LOWER: 0, UPPER: 0}
+	if !joinBounds(acceptBounds, bounds{LOWER: 5}, bounds{}, 2) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, gapLower(0): 1, gapUpper(0): 4}) {
+		t.Fail()
+	}
+	if joinBounds(acceptBounds, bounds{LOWER: 7, UPPER: 9}, bounds{}, 2) {
+		t.Fail()
+	}
+
+	acceptBounds = bounds{LOWER: 0, UPPER: 0}
+	joinBounds(acceptBounds, bounds{LOWER: 10, UPPER: 10}, bounds{}, 2)
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 10, gapLower(0): 1, gapUpper(0): 9}) {
+		t.Fail()
+	}
+	//a third interval doesn't fit, so the smallest gap is filled
+	if !joinBounds(acceptBounds, bounds{LOWER: 7, UPPER: 7}, bounds{}, 2) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 10, gapLower(0): 1, gapUpper(0): 6}) {
+		t.Fail()
+	}
+	if !joinBounds(acceptBounds, bounds{LOWER: 3, UPPER: 3}, bounds{}, 1) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptBounds, bounds{LOWER: 0, UPPER: 10}) {
+		t.Fail()
+	}
+}
+
 func TestFindClosure(t *testing.T) {
 	t.Run("Incomplete", func(t *testing.T) {
 		tm := turingMachine{
@@ -188,7 +281,7 @@ func TestMITMWFARdecider(t *testing.T) {
 				1: {0, L, E}},
 		},
 	}
-	if !MITMWFARdecider(tm, 9, 4, 4, 1, 0, -1) {
+	if !MITMWFARdecider(tm, 9, 4, 4, 1, 0, 0, searchOptions{}, -1) {
 		t.Fail()
 	}
 }
@@ -210,7 +303,7 @@ func TestMITMDFAdecider(t *testing.T) {
 					1: {0, L, A}},
 			},
 		}
-		if !MITMWFARdecider(tm, 9, 5, 5, 0, 0, -1) {
+		if !MITMWFARdecider(tm, 9, 5, 5, 0, 0, 0, searchOptions{}, -1) {
 			t.Fail()
 		}
 	})
@@ -230,7 +323,7 @@ func TestMITMDFAdecider(t *testing.T) {
 				E: {1: {0, R, A}},
 			},
 		}
-		if MITMWFARdecider(tm, 12, 4, 4, 0, 0, -1) {
+		if MITMWFARdecider(tm, 12, 4, 4, 0, 0, 0, searchOptions{}, -1) {
 			t.Fail()
 		}
 	})
@@ -273,3 +366,123 @@ func TestAddWFAMemory(t *testing.T) {
 	}
 
 }
+
+func TestWeightSlots(t *testing.T) {
+	wfa, _ := parseWFA("0,0;2,0_1,0;1,0_3,0;2,0_1,0;2,0")
+	expected := []weightSlot{{0, 1}, {2, 0}, {2, 1}, {3, 1}}
+	if !reflect.DeepEqual(weightSlots(wfa), expected) {
+		t.Error(weightSlots(wfa))
+	}
+}
+
+func TestRecursiveWeightAdder(t *testing.T) {
+	//the TM of TestMaxWeight, whose proof puts both weight pairs on a single transition of the right dfa.
+	//without skipping the pairs that were already tried, several proofs have the same pair up to potentials and mirroring
+	tm, _ := parseTM("1RB0LB_0RE1LA_0LB---_0LB0RE_1RD1LC")
+	leftWFA, _ := parseWFA("0,0;0,0_1,0;1,0")
+	rightWFA, _ := parseWFA("0,0;2,0_1,0;1,0_0,0;1,0")
+	options := searchOptions{maxWeight: 2, allProofs: 1}.forTM()
+	recursiveWeightAdder(tm, leftWFA, rightWFA, 0, 0, 2, 0, 0, options, -1)
+	if _, ok := options.proofs.proofs["0,0;0,0|0,0;1,0_0,-2;2,0_2,0;2,0"]; !ok {
+		t.Error(options.proofs.proofs)
+	}
+	checkProofs(t, options.proofs)
+}
+
+func TestWeightsAfterMemory(t *testing.T) {
+	//the weights of the proof depend on the previous symbol, which no weights on the base dfa can express
+	tm, _ := parseTM("1RB---_0LC1RA_1LB0LD_1LA1LC")
+	for _, weightsAfterMemory := range []bool{false, true} {
+		options := searchOptions{weightsAfterMemory: weightsAfterMemory, allProofs: 1}.forTM()
+		for transitions := 2; transitions <= 5; transitions++ {
+			MITMWFARdecider(tm, transitions, 5, 5, 1, 1, 1, options, -1)
+		}
+		if (len(options.proofs.proofs) > 0) != weightsAfterMemory {
+			t.Fatal(weightsAfterMemory, len(options.proofs.proofs))
+		}
+		//a single weight pair on the wfa with memory, where a weight copied from the base dfa would be on several transitions
+		for _, proof := range options.proofs.proofs {
+			if proof.weightedTransitions != 2 {
+				t.Error(proof.certificate)
+			}
+		}
+	}
+}
+
+func TestMemoryEscalation(t *testing.T) {
+	expected := [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}, {1, 2}}
+	if !reflect.DeepEqual(memoryEscalation(1, 2, true), expected) {
+		t.Error(memoryEscalation(1, 2, true))
+	}
+	if !reflect.DeepEqual(memoryEscalation(1, 2, false), [][2]int{{1, 2}}) {
+		t.Error(memoryEscalation(1, 2, false))
+	}
+}
+
+func TestOneSidedMemory(t *testing.T) {
+	//only memory on the right wfa solves the TM, so the same memory on the left or none at all doesn't
+	tm, _ := parseTM("1RB---_1LD1RA_0LA1LC_1LC1RD")
+	for _, memory := range [][2]int{{0, 0}, {1, 0}, {0, 1}} {
+		options := searchOptions{allProofs: 1}.forTM()
+		for transitions := 2; transitions <= 5; transitions++ {
+			MITMWFARdecider(tm, transitions, 5, 5, 1, memory[0], memory[1], options, -1)
+		}
+		if (len(options.proofs.proofs) > 0) != (memory == [2]int{0, 1}) {
+			t.Error(memory, len(options.proofs.proofs))
+		}
+		for _, proof := range options.proofs.proofs {
+			if !strings.HasPrefix(proof.certificate, "1RB---_1LD1RA_0LA1LC_1LC1RD\n0,0;2,0_1,0;1,0_1,0;2,0\n0,0;2,0_1,0;1,0_0,0;2,0\n") {
+				t.Error(proof.certificate)
+			}
+		}
+	}
+}
+
+func TestWeightPermutations(t *testing.T) {
+	if !reflect.DeepEqual(weightPermutations(0, 2), [][2]weight{{1, -1}, {-1, 1}}) {
+		t.Error(weightPermutations(0, 2))
+	}
+	//for a single pair {2, -2} and {2, 2} are multiples of {1, -1} and {1, 1}
+	expected := [][2]weight{{1, -1}, {1, -2}, {2, -1}, {1, 1}, {1, 2}, {2, 1}, {-1, 1}, {-1, 2}, {-2, 1}, {-1, -1}, {-1, -2}, {-2, -1}}
+	if !reflect.DeepEqual(weightPermutations(2, 1), expected) {
+		t.Error(weightPermutations(2, 1))
+	}
+	expected = [][2]weight{{1, -1}, {1, -2}, {2, -1}, {2, -2}, {1, 1}, {1, 2}, {2, 1}, {2, 2}, {-1, 1}, {-1, 2}, {-2, 1}, {-2, 2}, {-1, -1}, {-1, -2}, {-2, -1}, {-2, -2}}
+	if !reflect.DeepEqual(weightPermutations(2, 2), expected) {
+		t.Error(weightPermutations(2, 2))
+	}
+}
+
+func TestMaxWeight(t *testing.T) {
+	//pairs of 1 and -1 keep the weights of the left wfa summing to the negated weights of the right one, but every proof
+	//of this TM within 5 transitions and 2 weight pairs breaks that
+	tm, _ := parseTM("1RB0LB_0RE1LA_0LB---_0LB0RE_1RD1LC")
+	for _, maxWeight := range []int{0, 2} {
+		options := searchOptions{maxWeight: maxWeight, allProofs: 1}.forTM()
+		for transitions := 2; transitions <= 5; transitions++ {
+			MITMWFARdecider(tm, transitions, 5, 5, 2, 0, 0, options, -1)
+		}
+		if maxWeight == 0 {
+			if len(options.proofs.proofs) != 0 {
+				t.Error(len(options.proofs.proofs))
+			}
+			continue
+		}
+		if _, ok := options.proofs.proofs["0,0;0,0|0,0;1,0_0,-2;2,0_2,0;2,0"]; !ok {
+			t.Error(options.proofs.proofs)
+		}
+		checkProofs(t, options.proofs)
+	}
+}
+
+func TestCanHaveSign(t *testing.T) {
+	//a positive weight can only grow, so the states never get below 0
+	positive, _ := parseWFA("0,0;1,1_1,0;1,0")
+	if canHaveSign(deriveSpecialSets(positive), -1) || !canHaveSign(deriveSpecialSets(positive), 1) {
+		t.Error(deriveSpecialSets(positive))
+	}
+	unweighted, _ := parseWFA("0,0;1,0_1,0;1,0")
+	if canHaveSign(deriveSpecialSets(unweighted), -1) || canHaveSign(deriveSpecialSets(unweighted), 1) {
+		t.Error(deriveSpecialSets(unweighted))
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+//"(1,-2)" for a weight vector, just the weight for a single coordinate
+func weightVectorString(weights weightVector) string {
+	values := []string{}
+	for _, weight := range weights {
+		values = append(values, fmt.Sprint(weight))
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return "(" + strings.Join(values, ",") + ")"
+}
+
+//what the special sets claim about the weights in a state: "=0", ">=0", "<=0", "in [-2,3]", "%0;2" or "unreachable", and whether
+//that restricts the weight. An interval without finite bounds or all residues modulo the modulus claim nothing and get no label
+func specialSetLabel(s specialSets, state wfaState, modulus weight) (string, bool) {
+	switch {
+	case s.residues != nil:
+		residues, ok := s.residues[state]
+		if !ok {
+			return "unreachable", false
+		}
+		if weight(len(residues)) >= modulus {
+			return "", false
+		}
+		return "%" + strings.ReplaceAll(sortedResidues(residues), ",", ";"), true
+	case s.intervals != nil:
+		stateBounds, ok := s.intervals[state]
+		if !ok {
+			return "unreachable", false
+		}
+		lowerbound, hasLowerbound := stateBounds[LOWER]
+		upperbound, hasUpperbound := stateBounds[UPPER]
+		switch {
+		case hasLowerbound && hasUpperbound && lowerbound == upperbound:
+			return fmt.Sprintf("=%v", lowerbound), true
+		case hasLowerbound && hasUpperbound:
+			return fmt.Sprintf("in [%v,%v]", lowerbound, upperbound), true
+		case hasLowerbound:
+			return fmt.Sprintf(">=%v", lowerbound), true
+		case hasUpperbound:
+			return fmt.Sprintf("<=%v", upperbound), true
+		}
+	case s.nonNegative.contains(state) && s.nonPositive.contains(state):
+		return "=0", true
+	case s.nonNegative.contains(state):
+		return ">=0", true
+	case s.nonPositive.contains(state):
+		return "<=0", true
+	}
+	return "", false
+}
+
+//the wfa as a DOT graph. Edges are labelled with the symbol and the weight if it isn't 0,
+//the start state is bold and states are labelled with what the special sets claim. States where that restricts the weight are filled
+func wfaDOT(name string, wfa vectorWFA, specialSets vectorSpecialSets) string {
+	result := fmt.Sprintf("digraph \"%v\" {\n\trankdir=LR\n", name)
+	for i := 0; i < wfa[0].states; i++ {
+		state := wfaState(i)
+		labels := []string{}
+		restricted := false
+		for k, coordinateSets := range specialSets {
+			label, restricts := specialSetLabel(coordinateSets, state, wfa[k].modulus)
+			if label != "" {
+				labels = append(labels, label)
+			}
+			restricted = restricted || restricts
+		}
+		attributes := fmt.Sprintf("label=\"%v\"", state)
+		if len(labels) > 0 {
+			attributes = fmt.Sprintf("label=\"%v\\n%v\"", state, strings.Join(labels, " "))
+		}
+		if restricted {
+			attributes += " style=filled fillcolor=lightblue"
+		}
+		if state == wfa[0].startState {
+			attributes += " penwidth=3"
+		}
+		result += fmt.Sprintf("\t%v [%v]\n", state, attributes)
+	}
+	result += fmt.Sprintf("\tstart [shape=point]\n\tstart -> %v\n", wfa[0].startState)
+	for i := 0; i < wfa[0].states; i++ {
+		for j := 0; j < wfa[0].symbols; j++ {
+			transition, ok := wfa[0].transitions[wfaState(i)][symbol(j)]
+			if !ok {
+				continue
+			}
+			weights := weightVector{}
+			nonZero := false
+			for _, coordinate := range wfa {
+				weights = append(weights, coordinate.transitions[wfaState(i)][symbol(j)].weight)
+				nonZero = nonZero || weights[len(weights)-1] != 0
+			}
+			label := fmt.Sprint(j)
+			if nonZero {
+				label += "/" + weightVectorString(weights)
+			}
+			result += fmt.Sprintf("\t%v -> %v [label=\"%v\"]\n", i, transition.wfaState, label)
+		}
+	}
+	return result + "}\n"
+}
+
+func sortConfigs(configs []config) {
+	sort.Slice(configs, func(i, j int) bool {
+		a, b := configs[i], configs[j]
+		if a.tmState != b.tmState {
+			return a.tmState < b.tmState
+		}
+		if a.tmSymbol != b.tmSymbol {
+			return a.tmSymbol < b.tmSymbol
+		}
+		if a.leftState != b.leftState {
+			return a.leftState < b.leftState
+		}
+		return a.rightState < b.rightState
+	})
+}
+
+//the configs of the accept set as a DOT graph, labelled with their bounds. Each config has an edge to the
+//configs a TM transition can lead to, labelled with the weight change if it isn't 0.
+//next configs outside the accept set are left out, the special sets show that their weights can't be reached
+func acceptSetDOT(name string, tm turingMachine, leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet) string {
+	configs := []config{}
+	for config := range acceptSet {
+		configs = append(configs, config)
+	}
+	sortConfigs(configs)
+	start := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA[0].startState, rightWFA[0].startState}
+	result := fmt.Sprintf("digraph \"%v\" {\n", name)
+	for _, config := range configs {
+		attributes := fmt.Sprintf("label=\"%v\\n%v\"", config, acceptSet[config])
+		if config == start {
+			attributes += " penwidth=3"
+		}
+		result += fmt.Sprintf("\t\"%v\" [%v]\n", config, attributes)
+	}
+	for _, config := range configs {
+		nextConfigs := nextConfigsWithWeightVectorChange(config, tm, leftWFA, rightWFA)
+		sort.Slice(nextConfigs, func(i, j int) bool {
+			return nextConfigs[i].config.String() < nextConfigs[j].config.String()
+		})
+		for _, next := range nextConfigs {
+			if _, ok := acceptSet[next.config]; !ok {
+				continue
+			}
+			attributes := ""
+			for _, weight := range next.weightVector {
+				if weight != 0 {
+					attributes = fmt.Sprintf(" [label=\"%v\"]", weightVectorString(next.weightVector))
+					break
+				}
+			}
+			result += fmt.Sprintf("\t\"%v\" -> \"%v\"%v\n", config, next.config, attributes)
+		}
+	}
+	return result + "}\n"
+}
+
+//prints the left wfa, the right wfa and the accept set of each full certificate from the input as DOT graphs
+func runDOT(input *bufio.Scanner) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		if len(leftWFA) != len(rightWFA) || len(leftSpecialSets) != len(leftWFA) || len(rightSpecialSets) != len(rightWFA) {
+			fmt.Fprintln(os.Stderr, errorString("WFAs of different dimensions for TM: \""+tm.String()+"\""))
+			continue
+		}
+		if !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+			fmt.Fprintln(os.Stderr, errorString("Certificate doesn't verify: \""+tm.String()+"\""))
+			continue
+		}
+		fmt.Print(wfaDOT(tm.String()+" left", leftWFA, leftSpecialSets))
+		fmt.Print(wfaDOT(tm.String()+" right", rightWFA, rightSpecialSets))
+		fmt.Print(acceptSetDOT(tm.String()+" accept", tm, leftWFA, rightWFA, acceptSet))
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWFADOT(t *testing.T) {
+	wfa, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	result := wfaDOT("right", wfa, deriveVectorSpecialSets(wfa))
+	for _, line := range []string{
+		"digraph \"right\" {",
+		"\t0 [label=\"0\\n<=0\" style=filled fillcolor=lightblue penwidth=3]",
+		"\tstart -> 0",
+		"\t0 -> 1 [label=\"1\"]",
+		"\t1 -> 0 [label=\"0/-1\"]",
+	} {
+		if !strings.Contains(result, line+"\n") {
+			t.Error(line)
+		}
+	}
+	//state 2 can have any weight, which isn't worth highlighting
+	unbounded, _ := parseVectorWFA("0,0;2,0_1,0;1,0_2,1;2,-1")
+	result = wfaDOT("unbounded", unbounded, deriveVectorSpecialSets(unbounded))
+	for _, line := range []string{"\t0 [label=\"0\\n=0\" style=filled fillcolor=lightblue penwidth=3]", "\t2 [label=\"2\"]"} {
+		if !strings.Contains(result, line+"\n") {
+			t.Error(line)
+		}
+	}
+	residues := specialSets{residues: map[wfaState]set[weight]{0: {0: {}, 1: {}}, 2: {1: {}}}}
+	for state, expected := range map[wfaState]string{0: "", 1: "unreachable", 2: "%1"} {
+		if label, restricts := specialSetLabel(residues, state, 2); label != expected || restricts != (state == 2) {
+			t.Error(state, label, restricts)
+		}
+	}
+	vectorWFA, _ := parseVectorWFA("0,0,1;1,0,0_0,-1,0;1,0,0")
+	if !strings.Contains(wfaDOT("left", vectorWFA, deriveVectorSpecialSets(vectorWFA)), "\t0 -> 0 [label=\"0/(0,1)\"]\n") {
+		t.Fail()
+	}
+}
+
+func TestAcceptSetDOT(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	acceptSet, _ := parseVectorAcceptSet(findAcceptSet(tm, leftWFA[0], rightWFA[0], deriveSpecialSets(leftWFA[0]), deriveSpecialSets(rightWFA[0]), searchOptions{}).String())
+	result := acceptSetDOT("accept", tm, leftWFA, rightWFA, acceptSet)
+	if strings.Count(result, " [label=\"") < len(acceptSet) {
+		t.Fail()
+	}
+	//the first step of the TM, with the start config in bold
+	for _, line := range []string{
+		"\t\"A,0,0,0\" [label=\"A,0,0,0\\n0,0\" penwidth=3]",
+		"\t\"A,0,0,0\" -> \"B,0,2,0\"",
+	} {
+		if !strings.Contains(result, line+"\n") {
+			t.Error(line)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import "fmt"
+
+//what the search for a single TM ran into, to tell which parameters might solve it.
+//all methods do nothing on nil statistics, which is how the search runs unless it is asked to explain itself
+type searchStatistics struct {
+	//base dfa pairs that were closed under TM transitions and then weighted
+	closedPairs int
+	//weighted wfa pairs an accept set was built for
+	weightPlacements int
+	//the accept set search ran into a halting config, which leaves it empty
+	haltingConfigs int
+	//the accept set was built but didn't pass the verifier
+	verificationFailures int
+	//closed pairs the linear program of -lp found no weights for
+	infeasiblePrograms int
+	//the wfa pair whose accept set failed verification with the fewest bad entries
+	closestBadEntries               int
+	closestLeftWFA, closestRightWFA vectorWFA
+}
+
+func (s *searchStatistics) closedPair() {
+	if s != nil {
+		s.closedPairs += 1
+	}
+}
+
+func (s *searchStatistics) infeasibleProgram() {
+	if s != nil {
+		s.infeasiblePrograms += 1
+	}
+}
+
+//counts an accept set that didn't lead to a proof
+func (s *searchStatistics) rejectedAcceptSet(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) {
+	if s == nil {
+		return
+	}
+	s.weightPlacements += 1
+	if len(acceptSet) == 0 {
+		s.haltingConfigs += 1
+		return
+	}
+	s.verificationFailed(badAcceptSetEntries(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet), vectorWFA{leftWFA}, vectorWFA{rightWFA})
+}
+
+func (s *searchStatistics) rejectedVectorAcceptSet(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) {
+	if s == nil {
+		return
+	}
+	s.weightPlacements += 1
+	if len(acceptSet) == 0 {
+		s.haltingConfigs += 1
+		return
+	}
+	s.verificationFailed(badVectorAcceptSetEntries(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet), leftWFA, rightWFA)
+}
+
+func (s *searchStatistics) verificationFailed(badEntries int, leftWFA, rightWFA vectorWFA) {
+	s.verificationFailures += 1
+	if s.closestLeftWFA == nil || badEntries < s.closestBadEntries {
+		s.closestBadEntries = badEntries
+		s.closestLeftWFA = copyVectorWFA(leftWFA)
+		s.closestRightWFA = copyVectorWFA(rightWFA)
+	}
+}
+
+//the accept set entries the verifier objects to: halting configs and configs with a next config that isn't accepted
+func badAcceptSetEntries(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) int {
+	result := 0
+	for config, bounds := range acceptSet {
+		if haltsNextStep(tm, config.tmState, config.tmSymbol) {
+			result += 1
+			continue
+		}
+		for _, nextConfigWithWeightChange := range nextConfigsWithWeightChange(config, tm, leftWFA, rightWFA) {
+			if !nextConfigWithWeightChangeIsAccepted(nextConfigWithWeightChange, bounds, leftSpecialSets, rightSpecialSets, acceptSet) {
+				result += 1
+				break
+			}
+		}
+	}
+	return result
+}
+
+func badVectorAcceptSetEntries(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) int {
+	moduli := leftWFA.moduli()
+	result := 0
+	for config, polyhedron := range acceptSet {
+		if haltsNextStep(tm, config.tmState, config.tmSymbol) {
+			result += 1
+			continue
+		}
+		for _, nextConfigWithWeightVectorChange := range nextConfigsWithWeightVectorChange(config, tm, leftWFA, rightWFA) {
+			if !nextConfigWithWeightVectorChangeIsAccepted(nextConfigWithWeightVectorChange, polyhedron, leftSpecialSets, rightSpecialSets, moduli, acceptSet) {
+				result += 1
+				break
+			}
+		}
+	}
+	return result
+}
+
+//prints the statistics of a TM the search didn't solve
+func (s *searchStatistics) report(tm turingMachine, solved bool) {
+	if s == nil || solved {
+		return
+	}
+	fmt.Println(s.explanation(tm))
+}
+
+//the statistics of the search for the TM on a line
+func (s *searchStatistics) explanation(tm turingMachine) string {
+	result := fmt.Sprintf("%v unsolved: %v closed dfa pairs, %v weight placements, %v halting configs reached, %v verification failures",
+		tm, s.closedPairs, s.weightPlacements, s.haltingConfigs, s.verificationFailures)
+	if s.infeasiblePrograms > 0 {
+		result += fmt.Sprintf(", %v infeasible linear programs", s.infeasiblePrograms)
+	}
+	if s.closestLeftWFA != nil {
+		result += fmt.Sprintf(", closest with %v bad accept set entries: %v %v", s.closestBadEntries, s.closestLeftWFA, s.closestRightWFA)
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestSearchStatistics(t *testing.T) {
+	//a TM that halts after 3 steps, so every accept set runs into a halting config
+	tm, _ := parseTM("1RB---_1LB0RA")
+	options := searchOptions{explain: true}.forTM()
+	if MITMWFARdecider(tm, 4, 9, 9, 1, 0, 0, options, -1) {
+		t.FailNow()
+	}
+	expected := "1RB---_1LB0RA unsolved: 2 closed dfa pairs, 4 weight placements, 4 halting configs reached, 0 verification failures"
+	if options.statistics.explanation(tm) != expected {
+		t.Error(options.statistics.explanation(tm))
+	}
+	options = searchOptions{explain: true, solveWeights: true}.forTM()
+	if MITMWFARdecider(tm, 4, 9, 9, 0, 0, 0, options, -1) {
+		t.FailNow()
+	}
+	expected = "1RB---_1LB0RA unsolved: 2 closed dfa pairs, 2 weight placements, 2 halting configs reached, 0 verification failures, 2 infeasible linear programs"
+	if options.statistics.explanation(tm) != expected {
+		t.Error(options.statistics.explanation(tm))
+	}
+	//the search shares the statistics of the TM, without explain there are none
+	if (searchOptions{}).forTM().statistics != nil {
+		t.Fail()
+	}
+}
+
+func TestClosestRejectedAcceptSet(t *testing.T) {
+	tm, _ := parseTM("1RB---_1LB0RA")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_2,0;2,0")
+	rightWFA, _ := parseWFA("0,0;2,1_1,0;1,0_2,0;2,0")
+	leftSpecialSets := deriveSpecialSets(leftWFA)
+	rightSpecialSets := deriveSpecialSets(rightWFA)
+	//the initial config leads to B,0,2,0, which isn't accepted, and A,1,0,0 halts
+	start := acceptSet{config{A, 0, 0, 0}: bounds{LOWER: 0, UPPER: 0}}
+	halting := acceptSet{config{A, 0, 0, 0}: bounds{LOWER: 0, UPPER: 0}, config{A, 1, 0, 0}: bounds{LOWER: 0, UPPER: 0}}
+	if badAcceptSetEntries(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, start) != 1 ||
+		badAcceptSetEntries(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, halting) != 2 {
+		t.FailNow()
+	}
+	statistics := &searchStatistics{}
+	statistics.rejectedAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet{})
+	statistics.rejectedAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, halting)
+	statistics.rejectedAcceptSet(tm, rightWFA, leftWFA, rightSpecialSets, leftSpecialSets, start)
+	expected := "1RB---_1LB0RA unsolved: 0 closed dfa pairs, 3 weight placements, 1 halting configs reached, 2 verification failures, closest with 1 bad accept set entries: 0,0;2,1_1,0;1,0_2,0;2,0 0,0;2,0_1,0;1,0_2,0;2,0"
+	if statistics.explanation(tm) != expected {
+		t.Error(statistics.explanation(tm))
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import "math/big"
+
+//a system of linear inequalities coefficients·x >= bound over the rationals. All variables are free.
+type linearProgram struct {
+	variables int
+	rows      []map[int]weight
+	bounds    []weight
+}
+
+func (lp *linearProgram) addVariable() int {
+	lp.variables += 1
+	return lp.variables - 1
+}
+
+func (lp *linearProgram) addConstraint(coefficients map[int]weight, bound weight) {
+	lp.rows = append(lp.rows, coefficients)
+	lp.bounds = append(lp.bounds, bound)
+}
+
+//phase one of the simplex method in exact arithmetic. Every free variable x is split into x = positive - negative,
+//every row gets a slack variable and rows that aren't satisfied by x = 0 get an artificial variable whose sum is minimized.
+//the rows of the tableau are kept as integers without common divisor, so the value of a basic variable is the
+//right hand side divided by its coefficient. returns a feasible point or false if there is none or an integer would overflow
+func (lp linearProgram) solve() (result []*big.Rat, feasible bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, feasible = nil, false
+		}
+	}()
+	n := lp.variables
+	m := len(lp.rows)
+	artificialRows := []int{}
+	for i := range lp.rows {
+		if lp.bounds[i] > 0 {
+			artificialRows = append(artificialRows, i)
+		}
+	}
+	columns := 2*n + m + len(artificialRows)
+	//the column behind the last variable is the right hand side
+	rhs := columns
+	tableau := make([][]weight, m)
+	basis := make([]int, m)
+	for i, row := range lp.rows {
+		//rows with a positive bound get an artificial variable, all others are negated so their slack can start in the basis
+		sign := weight(-1)
+		if lp.bounds[i] > 0 {
+			sign = 1
+		}
+		tableau[i] = make([]weight, columns+1)
+		for variable, coefficient := range row {
+			tableau[i][variable] = sign * coefficient
+			tableau[i][n+variable] = -sign * coefficient
+		}
+		tableau[i][2*n+i] = -sign
+		tableau[i][rhs] = sign * lp.bounds[i]
+		basis[i] = 2*n + i
+	}
+	for k, i := range artificialRows {
+		tableau[i][2*n+m+k] = 1
+		basis[i] = 2*n + m + k
+	}
+	//reduced costs of minimizing the sum of the artificial variables, the right hand side is minus the objective value
+	cost := make([]weight, columns+1)
+	for _, i := range artificialRows {
+		for j := range cost {
+			if j < 2*n+m || j == rhs {
+				cost[j] -= tableau[i][j]
+				check(cost[j])
+			}
+		}
+	}
+	rows := append(tableau, cost)
+	//Bland's rule: the first improving column enters and ties leave by the smallest basic variable, so it can't cycle
+	for {
+		entering := -1
+		for j := 0; j < columns; j++ {
+			if cost[j] < 0 {
+				entering = j
+				break
+			}
+		}
+		if entering < 0 {
+			break
+		}
+		leaving := -1
+		for i := range tableau {
+			if tableau[i][entering] <= 0 {
+				continue
+			}
+			if leaving < 0 {
+				leaving = i
+				continue
+			}
+			//compares the ratios rhs/coefficient of both rows
+			difference := multiply(tableau[i][rhs], tableau[leaving][entering]) - multiply(tableau[leaving][rhs], tableau[i][entering])
+			if difference < 0 || (difference == 0 && basis[i] < basis[leaving]) {
+				leaving = i
+			}
+		}
+		if leaving < 0 {
+			//the objective is bounded below by 0, so this can't happen
+			return nil, false
+		}
+		pivot(rows, leaving, entering)
+		basis[leaving] = entering
+	}
+	if cost[rhs] != 0 {
+		return nil, false
+	}
+	values := make([]*big.Rat, columns)
+	for j := range values {
+		values[j] = new(big.Rat)
+	}
+	for i, column := range basis {
+		values[column] = big.NewRat(int64(tableau[i][rhs]), int64(tableau[i][column]))
+	}
+	result = make([]*big.Rat, n)
+	for variable := range result {
+		result[variable] = new(big.Rat).Sub(values[variable], values[n+variable])
+	}
+	return result, true
+}
+
+//eliminates the pivot column from all other rows, the last one are the reduced costs. They get multiplied by the positive pivot element first,
+//so the coefficients of the basic variables and the signs of the reduced costs stay the same
+func pivot(rows [][]weight, pivotRow, pivotColumn int) {
+	row := rows[pivotRow]
+	pivotElement := row[pivotColumn]
+	nonZero := []int{}
+	for j := range row {
+		if row[j] != 0 {
+			nonZero = append(nonZero, j)
+		}
+	}
+	for i, otherRow := range rows {
+		factor := otherRow[pivotColumn]
+		if i == pivotRow || factor == 0 {
+			continue
+		}
+		if pivotElement != 1 {
+			for j, value := range otherRow {
+				if value != 0 {
+					otherRow[j] = multiply(value, pivotElement)
+				}
+			}
+		}
+		for _, j := range nonZero {
+			otherRow[j] -= multiply(factor, row[j])
+			check(otherRow[j])
+		}
+		divisor := weight(0)
+		for _, value := range otherRow {
+			if value != 0 {
+				divisor = gcd(divisor, value)
+				if divisor == 1 {
+					break
+				}
+			}
+		}
+		if divisor > 1 {
+			for j, value := range otherRow {
+				if value != 0 {
+					otherRow[j] = value / divisor
+				}
+			}
+		}
+	}
+}
+
+//panics like check if the product doesn't fit
+func multiply(a, b weight) weight {
+	product := a * b
+	if a != 0 && product/a != b {
+		panic("possible integer overflow detected")
+	}
+	check(product)
+	return product
+}
+
+//------------------------------------------------------------------------------------------------
+
+//the variables of the linear program solveWeights uses
+type weightProgram struct {
+	linearProgram
+	leftWeights, rightWeights         map[wfaState]map[symbol]int
+	leftUpperBounds, rightUpperBounds map[wfaState]int
+	lowerBounds                       map[config]int
+}
+
+//tries to weight the transitions of the closed dwfa pair such that an accept set with only a lower bound
+//for each config excludes every halting config. With U(s) an upper bound on the weight of each wfa state
+//and L(c) the lower bound of each config, this is the linear program
+//   U(start) >= 0 and U(next) >= U(s) + weight for each wfa transition into a state a halting config needs,
+//   L(start) <= 0 and L(next) <= L(c) + weightChange for each step to a non halting config,
+//   L(c) + weightChange > U(left) + U(right) for each step to a halting config.
+//every constraint stays true if a solution is scaled up, so a rational solution gives integer weights.
+//accepting only upper bounds instead is the same program for negated weights.
+func solveWeights(tm turingMachine, leftWFA, rightWFA dwfa, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
+	leftWFA = liftWFA(leftWFA, addedMemoryLeft, options)
+	rightWFA = liftWFA(rightWFA, addedMemoryRight, options)
+	program, ok := newWeightProgram(tm, leftWFA, rightWFA)
+	if !ok {
+		options.statistics.infeasibleProgram()
+		return false
+	}
+	values, feasible := program.solve()
+	if !feasible {
+		options.statistics.infeasibleProgram()
+		return false
+	}
+	scale := big.NewInt(1)
+	for _, value := range values {
+		scale.Mul(scale, new(big.Int).Quo(value.Denom(), new(big.Int).GCD(nil, nil, scale, value.Denom())))
+	}
+	integerValue := func(variable int) (weight, bool) {
+		scaled := new(big.Int).Mul(values[variable].Num(), scale)
+		scaled.Quo(scaled, values[variable].Denom())
+		if !scaled.IsInt64() || scaled.Int64() > int64(MAXINT) || scaled.Int64() < int64(MININT) {
+			return 0, false
+		}
+		return weight(scaled.Int64()), true
+	}
+	for _, wfaWeights := range []struct {
+		wfa       dwfa
+		variables map[wfaState]map[symbol]int
+	}{{leftWFA, program.leftWeights}, {rightWFA, program.rightWeights}} {
+		for state, tmp := range wfaWeights.variables {
+			for symbol, variable := range tmp {
+				value, ok := integerValue(variable)
+				if !ok {
+					return false
+				}
+				wfaWeights.wfa.transitions[state][symbol] = wfaTransition{wfaWeights.wfa.transitions[state][symbol].wfaState, value}
+			}
+		}
+	}
+	leftSpecialSets := deriveSpecialSets(leftWFA)
+	rightSpecialSets := deriveSpecialSets(rightWFA)
+	//the accept set the search would find for these weights is preferred, as it can be reproduced from a short certificate
+	acceptSet := findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, options)
+	if len(acceptSet) > 0 && MITMWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		return scalarSimulationCheck(tm, leftWFA, rightWFA, acceptSet, options.simulationSteps) &&
+			options.proofs.add(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+	}
+	options.statistics.rejectedAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+	acceptSet = map[config]bounds{}
+	for config, variable := range program.lowerBounds {
+		value, ok := integerValue(variable)
+		if !ok {
+			return false
+		}
+		acceptSet[config] = bounds{LOWER: value}
+	}
+	return MITMWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) &&
+		scalarSimulationCheck(tm, leftWFA, rightWFA, acceptSet, options.simulationSteps) &&
+		options.proofs.add(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+}
+
+//builds the linear program of solveWeights. returns false if the dwfa pair isn't closed
+func newWeightProgram(tm turingMachine, leftWFA, rightWFA dwfa) (weightProgram, bool) {
+	program := weightProgram{}
+	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}
+	if haltsNextStep(tm, initialConfig.tmState, initialConfig.tmSymbol) {
+		return program, false
+	}
+	program.leftWeights, program.leftUpperBounds = addWFAVariables(&program.linearProgram, leftWFA)
+	program.rightWeights, program.rightUpperBounds = addWFAVariables(&program.linearProgram, rightWFA)
+	usedLeftStates := set[wfaState]{}
+	usedRightStates := set[wfaState]{}
+	program.lowerBounds = map[config]int{initialConfig: program.addVariable()}
+	program.addConstraint(map[int]weight{program.lowerBounds[initialConfig]: -1}, 0)
+	todo := []config{initialConfig}
+	for len(todo) > 0 {
+		currentConfig := todo[0]
+		todo = todo[1:]
+		tmTransition := tm.transitions[currentConfig.tmState][currentConfig.tmSymbol]
+		for _, tmp := range nextConfigsWithWeightChange(currentConfig, tm, leftWFA, rightWFA) {
+			nextConfig := tmp.config
+			if nextConfig.leftState == 1 || nextConfig.rightState == 1 {
+				return program, false
+			}
+			//the same weight change nextConfigsWithWeightChange computes, with the weights as variables
+			coefficients := map[int]weight{program.lowerBounds[currentConfig]: 1}
+			switch tmTransition.direction {
+			case L:
+				addWeight(coefficients, program.rightWeights, currentConfig.rightState, tmTransition.symbol, 1)
+				addWeight(coefficients, program.leftWeights, nextConfig.leftState, nextConfig.tmSymbol, -1)
+			case R:
+				addWeight(coefficients, program.leftWeights, currentConfig.leftState, tmTransition.symbol, 1)
+				addWeight(coefficients, program.rightWeights, nextConfig.rightState, nextConfig.tmSymbol, -1)
+			}
+			if haltsNextStep(tm, nextConfig.tmState, nextConfig.tmSymbol) {
+				addCoefficient(coefficients, program.leftUpperBounds[nextConfig.leftState], -1)
+				addCoefficient(coefficients, program.rightUpperBounds[nextConfig.rightState], -1)
+				program.addConstraint(coefficients, 1)
+				usedLeftStates.add(nextConfig.leftState)
+				usedRightStates.add(nextConfig.rightState)
+				continue
+			}
+			if _, ok := program.lowerBounds[nextConfig]; !ok {
+				program.lowerBounds[nextConfig] = program.addVariable()
+				todo = append(todo, nextConfig)
+			}
+			addCoefficient(coefficients, program.lowerBounds[nextConfig], -1)
+			program.addConstraint(coefficients, 0)
+		}
+	}
+	addUpperBoundConstraints(&program.linearProgram, leftWFA, program.leftWeights, program.leftUpperBounds, usedLeftStates)
+	addUpperBoundConstraints(&program.linearProgram, rightWFA, program.rightWeights, program.rightUpperBounds, usedRightStates)
+	return program, true
+}
+
+//adds a variable for the weight of every transition that may carry one and for the upper bound of every state.
+//the dead state 1 and the leading blank loop of the start state stay unweighted
+func addWFAVariables(lp *linearProgram, wfa dwfa) (map[wfaState]map[symbol]int, map[wfaState]int) {
+	weights := map[wfaState]map[symbol]int{}
+	upperBounds := map[wfaState]int{}
+	for state := 0; state < wfa.states; state++ {
+		if state != 1 {
+			upperBounds[wfaState(state)] = lp.addVariable()
+		}
+	}
+	for state, tmp := range wfa.transitions {
+		if state == 1 {
+			continue
+		}
+		weights[state] = map[symbol]int{}
+		for symbol, transition := range tmp {
+			if transition.wfaState != 1 && (state != wfa.startState || symbol != TMSTARTSYMBOL) {
+				weights[state][symbol] = lp.addVariable()
+			}
+		}
+	}
+	return weights, upperBounds
+}
+
+//makes the upper bounds of the used states and everything that can reach them valid.
+//the other states may have unbounded weights, as no halting config depends on them
+func addUpperBoundConstraints(lp *linearProgram, wfa dwfa, weights map[wfaState]map[symbol]int, upperBounds map[wfaState]int, used set[wfaState]) {
+	todo := []wfaState{}
+	for state := range used {
+		todo = append(todo, state)
+	}
+	for len(todo) > 0 {
+		currentState := todo[0]
+		todo = todo[1:]
+		if currentState == wfa.startState {
+			lp.addConstraint(map[int]weight{upperBounds[currentState]: 1}, 0)
+		}
+		for state, tmp := range wfa.transitions {
+			for symbol, transition := range tmp {
+				if state == 1 || transition.wfaState != currentState {
+					continue
+				}
+				coefficients := map[int]weight{}
+				addCoefficient(coefficients, upperBounds[currentState], 1)
+				addCoefficient(coefficients, upperBounds[state], -1)
+				addWeight(coefficients, weights, state, symbol, -1)
+				lp.addConstraint(coefficients, 0)
+				if !used.contains(state) {
+					used.add(state)
+					todo = append(todo, state)
+				}
+			}
+		}
+	}
+}
+
+func addCoefficient(coefficients map[int]weight, variable int, coefficient weight) {
+	coefficients[variable] += coefficient
+	if coefficients[variable] == 0 {
+		delete(coefficients, variable)
+	}
+}
+
+//unweighted transitions have no variable
+func addWeight(coefficients map[int]weight, weights map[wfaState]map[symbol]int, state wfaState, symbol symbol, coefficient weight) {
+	if variable, ok := weights[state][symbol]; ok {
+		addCoefficient(coefficients, variable, coefficient)
+	}
+}
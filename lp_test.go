@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestLinearProgram(t *testing.T) {
+	lp := linearProgram{}
+	x := lp.addVariable()
+	y := lp.addVariable()
+	//x - y >= 1, y >= 2, x <= 5
+	lp.addConstraint(map[int]weight{x: 1, y: -1}, 1)
+	lp.addConstraint(map[int]weight{y: 1}, 2)
+	lp.addConstraint(map[int]weight{x: -1}, -5)
+	values, feasible := lp.solve()
+	if !feasible {
+		t.FailNow()
+	}
+	if new(big.Rat).Sub(values[x], values[y]).Cmp(big.NewRat(1, 1)) < 0 || values[y].Cmp(big.NewRat(2, 1)) < 0 || values[x].Cmp(big.NewRat(5, 1)) > 0 {
+		t.Fail()
+	}
+	//x <= 2
+	lp.addConstraint(map[int]weight{x: -1}, -2)
+	if _, feasible := lp.solve(); feasible {
+		t.Fail()
+	}
+}
+
+func TestSolveWeights(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,0;4,0_1,0;5,0_3,0;4,0_3,0;4,0")
+	rightWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,0;4,0_0,0;2,0_3,0;4,0")
+	if !solveWeights(tm, leftWFA, rightWFA, 0, 0, searchOptions{}, -1) {
+		t.Fail()
+	}
+	//the weights are only set on copies
+	if leftWFA.transitions[2][0].weight != 0 {
+		t.Fail()
+	}
+	//without weights the dwfa pair doesn't work
+	leftSpecialSets := deriveSpecialSets(leftWFA)
+	rightSpecialSets := deriveSpecialSets(rightWFA)
+	acceptSet := findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, searchOptions{})
+	if MITMWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		t.Fail()
+	}
+}
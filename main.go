@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"flag"
+	"fmt"
 	"os"
 	"runtime"
 )
@@ -11,23 +12,59 @@ func main() {
 	//check certificates
 	fullcert := flag.Bool("fc", false, "reads the full certificate for TMs from stdin")
 	shortcert := flag.Bool("sc", false, "reads a short certificate for TMs from stdin")
+	enumerate := flag.Int("enum", 0, "reads full certificates from stdin and lists the tape configurations with up to this many cells per side they accept")
+	enumerateSteps := flag.Int("enumsteps", 10000, "with -enum: number of steps to simulate the TM for to find the configurations it reaches")
+	canonical := flag.Bool("canon", false, "reads full certificates from stdin and prints each TM with the minimal canonical forms of its WFAs")
+	minimize := flag.Bool("minimize", false, "reads full certificates with plain integer weights from stdin and prints the smallest equivalent certificate found for each")
+	trace := flag.Bool("trace", false, "reads short certificates from stdin and prints how the accept set search derived the bounds of each config")
+	spaceTime := flag.Int("spacetime", 0, "reads full certificates from stdin and writes the space-time diagram of this many steps of each TM to <TM>.svg")
+	dot := flag.Bool("dot", false, "reads full certificates from stdin and prints their WFAs and accept set as DOT graphs")
+	classify := flag.String("classify", "", "reads full certificates from stdin and tells whether they accept this tape configuration, e.g. \"1011[B0]0110\"")
 
 	//specify decider parameters directly
 	transitions := flag.Int("t", 8, "exact number of non-dead transitions in the combined WFAs")
 	leftStates := flag.Int("l", 4, "maximum number of states in the left WFA")
 	rightStates := flag.Int("r", 4, "maximum number of states in the left WFA")
 	weightPairs := flag.Int("w", 1, "maximum number of weighted transitions in each WFA")
+	maxWeight := flag.Int("wmax", 0, "place weight pairs with absolute values up to this instead of only 1 and -1, including pairs of the same sign. Ignored with -dim and -mod")
 	memory := flag.Int("m", 0, "memory added to each WFA")
+	leftMemory := flag.Int("ml", -1, "memory added to the left WFA, -m if negative. If -ml or -mr is given, scans try smaller memory on one side at a time first")
+	rightMemory := flag.Int("mr", -1, "memory added to the right WFA, -m if negative")
+	auxiliary := flag.String("aux", "", "multiply both WFAs with this DFA before building the accept set: lastK for the last K symbols, modK for the position modulo K, or a file with a DFA in WFA format")
+	memoryWeights := flag.Bool("mw", false, "place the weights on the WFAs after adding the memory of -m, so they can depend on the previous symbols")
+	dimensions := flag.Int("dim", 1, "number of independent counters the weights of the WFAs are split into")
+	moduli := flag.String("mod", "", "comma separated modulus of each counter, 0 for integer counters")
+	split := flag.Bool("split", false, "track the weights of the left and right WFA separately instead of their sum")
+	polyhedral := flag.Bool("poly", false, "bound the sum and difference of each pair of counters in the accept set")
+	congruences := flag.Bool("cong", false, "let the accept set restrict the weight sum to a residue class")
+	intervals := flag.Int("intervals", 1, "maximum number of disjoint intervals the accept set may accept per config")
+	solveWeights := flag.Bool("lp", false, "solve a linear program for the weights of each closed WFA pair")
 
 	//main modes
 	scan := flag.Int("n", 0, "scans up to this maximum number of non-dead transitions")
 	dfa := flag.Int("dfa", 0, "scans in MITM-DFA mode with this amount of states per side")
+	sat := flag.Int("sat", 0, "finds the base DFA pairs with a SAT solver, with up to this amount of states per side")
+	dimacs := flag.Bool("dimacs", false, "with -sat: prints the SAT problem in DIMACS format instead of solving it")
+
+	//prefilter
+	simulate := flag.Int("sim", 0, "simulates each TM for up to this many steps first and only searches the ones that don't halt, cycle or translated cycle")
+	simOutput := flag.String("simout", "", "file for the TMs the simulation decided, with their label. Default is stderr")
+	simCheck := flag.Int("simcheck", 0, "simulates each solved TM for this many steps and reports any escape from the accept set as a soundness bug")
 
 	//misc
+	allProofs := flag.Int("all", 0, "keeps searching after a proof and prints up to this many distinct full certificates for each TM, smallest first")
+	explain := flag.Bool("explain", false, "prints search statistics for each TM that isn't solved")
 	printMode := flag.Int("pm", 0, "what to print: 0 -> solved TMs, 1 -> short certificates, 2 -> full certificates")
 	cores := flag.Int("cores", 0, "maximum number of TMs to work on in parallel")
 
 	flag.Parse()
+	escalateMemory := *leftMemory >= 0 || *rightMemory >= 0
+	if *leftMemory < 0 {
+		*leftMemory = *memory
+	}
+	if *rightMemory < 0 {
+		*rightMemory = *memory
+	}
 
 	if *cores <= 0 {
 		*cores = runtime.GOMAXPROCS(0)
@@ -36,18 +73,64 @@ func main() {
 	for i := 0; i < *cores; i++ {
 		workTokens <- struct{}{}
 	}
+	options := searchOptions{dimensions: *dimensions, splitSides: *split, polyhedral: *polyhedral, congruences: *congruences, intervals: *intervals, solveWeights: *solveWeights, weightsAfterMemory: *memoryWeights, maxWeight: *maxWeight, simulationSteps: *simCheck, explain: *explain, allProofs: *allProofs}
+	if *auxiliary != "" {
+		var err error
+		options.auxiliary, err = parseAuxiliaryDFA(*auxiliary)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+	}
+	if *moduli != "" {
+		var err error
+		options.moduli, err = parseModuli(*moduli)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+	}
 	input := bufio.NewScanner(os.Stdin)
+	if *simulate > 0 && !*fullcert && !*shortcert && *classify == "" && *enumerate == 0 && !*dot && *spaceTime == 0 && !*trace && !*minimize && !*canonical {
+		output := os.Stderr
+		if *simOutput != "" {
+			file, err := os.Create(*simOutput)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer file.Close()
+			output = file
+		}
+		input = prefilterTMs(input, *simulate, output)
+	}
 	switch {
+	case *canonical:
+		runCanonical(input)
+	case *minimize:
+		runMinimize(input)
+	case *trace:
+		runTrace(input, options)
+	case *spaceTime > 0:
+		runSpaceTime(input, *spaceTime)
+	case *dot:
+		runDOT(input)
+	case *enumerate > 0:
+		runEnumerate(input, *enumerate, *enumerateSteps)
+	case *classify != "":
+		runClassify(input, *classify)
 	case *fullcert:
-		parseFullCertificate(input, workTokens, *printMode)
+		parseFullCertificate(input, workTokens, *printMode, *simCheck)
 	case *shortcert:
-		parseShortCertificate(input, workTokens, *printMode)
+		parseShortCertificate(input, workTokens, *printMode, *simCheck)
 	case *scan > 0:
-		runWeightedScan(input, workTokens, *printMode, *scan, *weightPairs, *memory)
+		runWeightedScan(input, workTokens, *printMode, *scan, *weightPairs, *leftMemory, *rightMemory, escalateMemory, options)
+	case *sat > 0:
+		runSATScan(input, workTokens, *printMode, *sat, *weightPairs, *leftMemory, *rightMemory, escalateMemory, options, *dimacs)
 	case *dfa > 0:
 		runDFAScan(input, workTokens, *printMode, *dfa)
 	default:
-		runSpecificValues(input, workTokens, *printMode, *transitions, *leftStates, *rightStates, *weightPairs, *memory)
+		runSpecificValues(input, workTokens, *printMode, *transitions, *leftStates, *rightStates, *weightPairs, *leftMemory, *rightMemory, options)
 	}
 
 	//make sure all the work is finished
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+//a certificate with plain integer weights whose special sets are derived from its wfa
+type scalarCertificate struct {
+	tm                                turingMachine
+	leftWFA, rightWFA                 dwfa
+	leftSpecialSets, rightSpecialSets specialSets
+	acceptSet                         acceptSet
+}
+
+func newScalarCertificate(tm turingMachine, leftWFA, rightWFA dwfa, acceptSet acceptSet) scalarCertificate {
+	return scalarCertificate{tm, leftWFA, rightWFA, deriveSpecialSets(leftWFA), deriveSpecialSets(rightWFA), acceptSet}
+}
+
+func (c scalarCertificate) verify() bool {
+	return MITMWFARverifier(c.tm, c.leftWFA, c.rightWFA, c.leftSpecialSets, c.rightSpecialSets, c.acceptSet, -1)
+}
+
+//certificates are ranked like the proofs of -all: by states, then accept set entries, then weighted transitions.
+//certificates of the same size are ranked by how tight their accept set is, so that narrowing it counts as well
+func (c scalarCertificate) smallerThan(other scalarCertificate) bool {
+	size := func(c scalarCertificate) proof {
+		return proof{"", c.leftWFA.states + c.rightWFA.states, len(c.acceptSet), weightedTransitions(vectorWFA{c.leftWFA}) + weightedTransitions(vectorWFA{c.rightWFA})}
+	}
+	if size(c).smallerThan(size(other)) || size(other).smallerThan(size(c)) {
+		return size(c).smallerThan(size(other))
+	}
+	missingBounds, width := c.acceptSet.looseness()
+	otherMissingBounds, otherWidth := other.acceptSet.looseness()
+	if missingBounds != otherMissingBounds {
+		return missingBounds < otherMissingBounds
+	}
+	return width < otherWidth
+}
+
+//the number of lower and upper bounds missing from the accept set and the total length of the intervals with both bounds
+func (as acceptSet) looseness() (int, weight) {
+	missingBounds, width := 0, weight(0)
+	for _, bounds := range as {
+		lowerbound, hasLowerbound := bounds[LOWER]
+		upperbound, hasUpperbound := bounds[UPPER]
+		if !hasLowerbound {
+			missingBounds += 1
+		}
+		if !hasUpperbound {
+			missingBounds += 1
+		}
+		if hasLowerbound && hasUpperbound {
+			width += upperbound - lowerbound
+		}
+	}
+	return missingBounds, width
+}
+
+//the classes of the wfa with state b merged into state a
+func mergedStates(wfa dwfa, a, b wfaState) (map[wfaState]wfaState, int) {
+	return numberClasses(wfa.states, func(state wfaState) (string, bool) {
+		if state == b {
+			state = a
+		}
+		return fmt.Sprint(state), true
+	})
+}
+
+func identityClasses(wfa dwfa) map[wfaState]wfaState {
+	classes, _ := numberClasses(wfa.states, func(state wfaState) (string, bool) { return fmt.Sprint(state), true })
+	return classes
+}
+
+//the smallest interval containing both bounds. Congruences and gaps are only kept if the bounds are the same
+func hullBounds(a, b bounds) bounds {
+	if a == nil {
+		return copyBounds(b)
+	}
+	if fmt.Sprint(a) == fmt.Sprint(b) {
+		return a
+	}
+	result := bounds{}
+	aLower, aHasLower := a[LOWER]
+	bLower, bHasLower := b[LOWER]
+	if aHasLower && bHasLower {
+		result[LOWER] = aLower
+		if bLower < aLower {
+			result[LOWER] = bLower
+		}
+	}
+	aUpper, aHasUpper := a[UPPER]
+	bUpper, bHasUpper := b[UPPER]
+	if aHasUpper && bHasUpper {
+		result[UPPER] = aUpper
+		if bUpper > aUpper {
+			result[UPPER] = bUpper
+		}
+	}
+	return result
+}
+
+//the accept set with the wfa states mapped to their classes. Entries of merged configs are joined and
+//entries with a dropped state are left out
+func quotientAcceptSet(acceptSet acceptSet, leftClasses, rightClasses map[wfaState]wfaState) acceptSet {
+	result := map[config]bounds{}
+	for oldConfig, bounds := range acceptSet {
+		leftClass, leftOk := leftClasses[oldConfig.leftState]
+		rightClass, rightOk := rightClasses[oldConfig.rightState]
+		if !leftOk || !rightOk {
+			continue
+		}
+		newConfig := config{oldConfig.tmState, oldConfig.tmSymbol, leftClass, rightClass}
+		result[newConfig] = hullBounds(result[newConfig], bounds)
+	}
+	return result
+}
+
+//a certificate for changed wfa: the given accept set if it still works, otherwise the one the search would find
+func withWFA(tm turingMachine, leftWFA, rightWFA dwfa, acceptSet acceptSet) (scalarCertificate, bool) {
+	certificate := newScalarCertificate(tm, leftWFA, rightWFA, acceptSet)
+	if acceptSet != nil && certificate.verify() {
+		return certificate, true
+	}
+	for _, options := range []searchOptions{{}, {congruences: true}, {intervals: SHORTCERTINTERVALS}} {
+		certificate.acceptSet = findAcceptSet(tm, leftWFA, rightWFA, certificate.leftSpecialSets, certificate.rightSpecialSets, options)
+		if len(certificate.acceptSet) > 0 && certificate.verify() {
+			return certificate, true
+		}
+	}
+	return certificate, false
+}
+
+//one step of narrowing: every entry only keeps the weights that its predecessors in the accept set can lead to.
+//if the accept set is closed under TM transitions the result is closed as well
+func (c scalarCertificate) narrowed() acceptSet {
+	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, c.leftWFA.startState, c.rightWFA.startState}
+	result := acceptSet{initialConfig: bounds{LOWER: 0, UPPER: 0}}
+	for config, bounds := range c.acceptSet {
+		for _, next := range nextConfigsWithWeightChange(config, c.tm, c.leftWFA, c.rightWFA) {
+			hardBounds, reachable := sumOfStateBounds(c.leftSpecialSets, c.rightSpecialSets, next.config.leftState, next.config.rightState)
+			if !reachable {
+				continue
+			}
+			nextBounds, nonEmpty := clipBounds(shiftBounds(bounds, next.weight), hardBounds)
+			if !nonEmpty {
+				continue
+			}
+			result[next.config] = hullBounds(result[next.config], nextBounds)
+		}
+	}
+	return result
+}
+
+//the configs of the accept set in a fixed order
+func (as acceptSet) sortedConfigs() []config {
+	result := []config{}
+	for config := range as {
+		result = append(result, config)
+	}
+	sortConfigs(result)
+	return result
+}
+
+//tries to make a verified certificate smaller while it still verifies: removing unreachable states, merging
+//bisimilar states, dropping weights, narrowing the accept set and removing accept set entries
+func minimizeCertificate(certificate scalarCertificate) scalarCertificate {
+	for {
+		best := certificate
+		//unreachable and bisimilar states
+		for _, classesOf := range []func(vectorWFA) (map[wfaState]wfaState, int){reachableStates, bisimulationClasses} {
+			leftClasses, leftCount := classesOf(vectorWFA{best.leftWFA})
+			rightClasses, rightCount := classesOf(vectorWFA{best.rightWFA})
+			if leftCount == best.leftWFA.states && rightCount == best.rightWFA.states {
+				continue
+			}
+			leftWFA := quotientWFA(best.leftWFA, leftClasses, leftCount)
+			rightWFA := quotientWFA(best.rightWFA, rightClasses, rightCount)
+			if candidate, ok := withWFA(best.tm, leftWFA, rightWFA, quotientAcceptSet(best.acceptSet, leftClasses, rightClasses)); ok {
+				best = candidate
+			}
+		}
+		//pairs of bisimilar states, in case merging all of them at once is too much.
+		//after a merge the states are renumbered, so the next pair is tried in the next round
+		for _, side := range []bool{true, false} {
+			wfa := best.leftWFA
+			if !side {
+				wfa = best.rightWFA
+			}
+			bisimulation, _ := bisimulationClasses(vectorWFA{wfa})
+			merged := false
+			for i := 0; i < wfa.states && !merged; i++ {
+				for j := i + 1; j < wfa.states && !merged; j++ {
+					if bisimulation[wfaState(i)] != bisimulation[wfaState(j)] {
+						continue
+					}
+					classes, count := mergedStates(wfa, wfaState(i), wfaState(j))
+					leftWFA, rightWFA := quotientWFA(wfa, classes, count), best.rightWFA
+					leftClasses, rightClasses := classes, identityClasses(best.rightWFA)
+					if !side {
+						leftWFA, rightWFA = best.leftWFA, quotientWFA(wfa, classes, count)
+						leftClasses, rightClasses = identityClasses(best.leftWFA), classes
+					}
+					if candidate, ok := withWFA(best.tm, leftWFA, rightWFA, quotientAcceptSet(best.acceptSet, leftClasses, rightClasses)); ok {
+						best = candidate
+						merged = true
+					}
+				}
+			}
+		}
+		//weights
+		for _, side := range []bool{true, false} {
+			wfa := best.leftWFA
+			if !side {
+				wfa = best.rightWFA
+			}
+			for i := 0; i < wfa.states; i++ {
+				for j := 0; j < wfa.symbols; j++ {
+					transition := wfa.transitions[wfaState(i)][symbol(j)]
+					if transition.weight == 0 {
+						continue
+					}
+					unweighted := copyWFA(wfa)
+					unweighted.transitions[wfaState(i)][symbol(j)] = wfaTransition{transition.wfaState, 0}
+					leftWFA, rightWFA := unweighted, best.rightWFA
+					if !side {
+						leftWFA, rightWFA = best.leftWFA, unweighted
+					}
+					if candidate, ok := withWFA(best.tm, leftWFA, rightWFA, best.acceptSet); ok {
+						best = candidate
+						wfa = unweighted
+					}
+				}
+			}
+		}
+		//the accept set
+		for i := 0; i < MAXNARROWINGSTEPS; i++ {
+			candidate := best
+			candidate.acceptSet = best.narrowed()
+			if fmt.Sprint(candidate.acceptSet) == fmt.Sprint(best.acceptSet) || !candidate.verify() {
+				break
+			}
+			best = candidate
+		}
+		for _, config := range best.acceptSet.sortedConfigs() {
+			candidate := best
+			candidate.acceptSet = acceptSet{}
+			for otherConfig, bounds := range best.acceptSet {
+				if otherConfig != config {
+					candidate.acceptSet[otherConfig] = bounds
+				}
+			}
+			if candidate.verify() {
+				best = candidate
+			}
+		}
+		if !best.smallerThan(certificate) {
+			return certificate
+		}
+		certificate = best
+	}
+}
+
+//the number of times the accept set is narrowed at most
+const MAXNARROWINGSTEPS = 100
+
+//minimizes each full certificate from the input that has plain integer weights and prints the result as a full certificate
+func runMinimize(input *bufio.Scanner) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, vectorAcceptSet, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		acceptSet, ok := scalarAcceptSet(vectorAcceptSet)
+		if !isScalarCertificate(leftWFA, rightWFA) || vectorAcceptSet.hasConstraints() || !ok {
+			fmt.Fprintln(os.Stderr, errorString("Only certificates with plain integer weights can be minimized: \""+tm.String()+"\""))
+			continue
+		}
+		if !MITMWFARverifier(tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], acceptSet, -1) {
+			fmt.Fprintln(os.Stderr, errorString("Certificate doesn't verify: \""+tm.String()+"\""))
+			continue
+		}
+		//the derived special sets might not be enough for the accept set, the search would find one that works with them
+		certificate, found := withWFA(tm, leftWFA[0], rightWFA[0], acceptSet)
+		if !found {
+			certificate = scalarCertificate{tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], acceptSet}
+		}
+		certificate = minimizeCertificate(certificate)
+		fmt.Print(certificateString(2, certificate.tm, certificate.leftWFA, certificate.rightWFA, certificate.leftSpecialSets, certificate.rightSpecialSets, certificate.acceptSet))
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestMinimizeCertificate(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	//the smallest wfa known for this TM with a copy of state 2 added to the left one
+	//and a state that can't be reached added to the right one
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;4,0_1,0;2,0_3,1;2,0")
+	rightWFA, _ := parseWFA("0,0;1,0_0,-1;1,0_0,1;2,0")
+	certificate, ok := withWFA(tm, leftWFA, rightWFA, nil)
+	if !ok {
+		t.FailNow()
+	}
+	minimized := minimizeCertificate(certificate)
+	if !minimized.verify() || !minimized.smallerThan(certificate) {
+		t.FailNow()
+	}
+	if minimized.leftWFA.states > 4 || minimized.rightWFA.states > 2 {
+		t.Error(minimized.leftWFA, minimized.rightWFA)
+	}
+}
+
+func TestMinimizeNarrowsAcceptSet(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseWFA("0,0;1,0_0,-1;1,0")
+	certificate, ok := withWFA(tm, leftWFA, rightWFA, nil)
+	if !ok {
+		t.FailNow()
+	}
+	//the same wfa with every upper bound of the accept set dropped, which only narrowing can bring back
+	loose := certificate
+	loose.acceptSet = acceptSet{}
+	for config, bounds := range certificate.acceptSet {
+		loose.acceptSet[config] = copyBounds(bounds)
+		delete(loose.acceptSet[config], UPPER)
+	}
+	looseMissing, _ := loose.acceptSet.looseness()
+	if !loose.verify() || loose.smallerThan(certificate) || !certificate.smallerThan(loose) {
+		t.FailNow()
+	}
+	minimized := minimizeCertificate(loose)
+	missing, _ := minimized.acceptSet.looseness()
+	if !minimized.verify() || !minimized.smallerThan(loose) || missing >= looseMissing {
+		t.Error(minimized.acceptSet)
+	}
+}
@@ -8,25 +8,69 @@ import (
 	"strings"
 )
 
-func parseFullCertificate(input *bufio.Scanner, workTokens chan struct{}, printMode int) {
+func parseFullCertificate(input *bufio.Scanner, workTokens chan struct{}, printMode, simulationSteps int) {
 	for input.Scan() {
-		tm, err := parseTM(input.Text())
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(input)
 		if err != nil {
 			if input.Text() != "" {
 				fmt.Fprintln(os.Stderr, err)
 			}
 			continue
 		}
-		input.Scan()
-		leftWFA, err := parseWFA(input.Text())
-		if err != nil {
-			if input.Text() != "" {
-				fmt.Fprintln(os.Stderr, err)
-			}
+		isScalar := isScalarCertificate(leftWFA, rightWFA) && !acceptSet.hasConstraints()
+		scalar, ok := scalarAcceptSet(acceptSet)
+		if isScalar && !ok {
+			fmt.Fprintln(os.Stderr, errorString("Accept set with several coordinates or residues for plain integer WFAs of TM: \""+tm.String()+"\""))
 			continue
 		}
-		input.Scan()
-		rightWFA, err := parseWFA(input.Text())
+		_ = <-workTokens
+		go func() {
+			if isScalar {
+				if MITMWFARverifier(tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], scalar, printMode) {
+					scalarSimulationCheck(tm, leftWFA[0], rightWFA[0], scalar, simulationSteps)
+				}
+			} else if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode) {
+				vectorSimulationCheck(tm, leftWFA, rightWFA, acceptSet, simulationSteps)
+			}
+			workTokens <- struct{}{}
+		}()
+	}
+}
+
+//parses the full certificate starting at the current line. On an error the scanner stays at the line that failed
+func scanFullCertificate(input *bufio.Scanner) (tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet, err error) {
+	tm, err = parseTM(input.Text())
+	if err != nil {
+		return
+	}
+	input.Scan()
+	leftWFA, err = parseVectorWFA(input.Text())
+	if err != nil {
+		return
+	}
+	input.Scan()
+	rightWFA, err = parseVectorWFA(input.Text())
+	if err != nil {
+		return
+	}
+	input.Scan()
+	leftSpecialSets, err = parseVectorSpecialSets(input.Text())
+	if err != nil {
+		return
+	}
+	input.Scan()
+	rightSpecialSets, err = parseVectorSpecialSets(input.Text())
+	if err != nil {
+		return
+	}
+	input.Scan()
+	acceptSet, err = parseVectorAcceptSet(input.Text())
+	return
+}
+
+func parseShortCertificate(input *bufio.Scanner, workTokens chan struct{}, printMode, simulationSteps int) {
+	for input.Scan() {
+		tm, err := parseTM(input.Text())
 		if err != nil {
 			if input.Text() != "" {
 				fmt.Fprintln(os.Stderr, err)
@@ -34,7 +78,7 @@ func parseFullCertificate(input *bufio.Scanner, workTokens chan struct{}, printM
 			continue
 		}
 		input.Scan()
-		leftSpecialSets, err := parseSpecialSets(input.Text())
+		leftWFA, err := parseVectorWFA(input.Text())
 		if err != nil {
 			if input.Text() != "" {
 				fmt.Fprintln(os.Stderr, err)
@@ -42,66 +86,130 @@ func parseFullCertificate(input *bufio.Scanner, workTokens chan struct{}, printM
 			continue
 		}
 		input.Scan()
-		rightSpecialSets, err := parseSpecialSets(input.Text())
+		rightWFA, err := parseVectorWFA(input.Text())
 		if err != nil {
 			if input.Text() != "" {
 				fmt.Fprintln(os.Stderr, err)
 			}
 			continue
 		}
-		input.Scan()
-		acceptSet, err := parseAcceptSet(input.Text())
-		if err != nil {
-			if input.Text() != "" {
-				fmt.Fprintln(os.Stderr, err)
-			}
+		if isScalarCertificate(leftWFA, rightWFA) {
+			leftSpecialSets := deriveSpecialSets(leftWFA[0])
+			rightSpecialSets := deriveSpecialSets(rightWFA[0])
+			_ = <-workTokens
+			go func() {
+				//the proof might need the parity (or another residue) of the weight sum or several disjoint intervals,
+				//but these are only tried if a single interval isn't enough
+				for _, options := range []searchOptions{{}, {congruences: true}, {intervals: SHORTCERTINTERVALS}} {
+					acceptSet := findAcceptSet(tm, leftWFA[0], rightWFA[0], leftSpecialSets, rightSpecialSets, options)
+					if MITMWFARverifier(tm, leftWFA[0], rightWFA[0], leftSpecialSets, rightSpecialSets, acceptSet, printMode) {
+						scalarSimulationCheck(tm, leftWFA[0], rightWFA[0], acceptSet, simulationSteps)
+						break
+					}
+				}
+				workTokens <- struct{}{}
+			}()
+			continue
+		}
+		if len(leftWFA) != len(rightWFA) {
+			fmt.Fprintln(os.Stderr, errorString("WFAs of different dimensions for TM: \""+tm.String()+"\""))
 			continue
 		}
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
 		_ = <-workTokens
 		go func() {
-			MITMWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode)
+			acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+			if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode) {
+				vectorSimulationCheck(tm, leftWFA, rightWFA, acceptSet, simulationSteps)
+			} else {
+				//the proof might need constraints between the counters, which boxes can't express
+				templates := octagonTemplates(leftWFA.moduli())
+				if len(templates) > 0 {
+					acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, templates)
+					if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode) {
+						vectorSimulationCheck(tm, leftWFA, rightWFA, acceptSet, simulationSteps)
+					}
+				}
+			}
 			workTokens <- struct{}{}
 		}()
 	}
 }
 
-func parseShortCertificate(input *bufio.Scanner, workTokens chan struct{}, printMode int) {
-	for input.Scan() {
-		tm, err := parseTM(input.Text())
-		if err != nil {
-			if input.Text() != "" {
-				fmt.Fprintln(os.Stderr, err)
-			}
-			continue
+//certificates with plain integer weights are checked by the original verifier
+func isScalarCertificate(leftWFA, rightWFA vectorWFA) bool {
+	return len(leftWFA) == 1 && len(rightWFA) == 1 && leftWFA[0].modulus == 0 && rightWFA[0].modulus == 0
+}
+
+//whether the full certificate passes the verifier that fits its weights, without printing it.
+//this also makes sure the wfa read the symbols of the TM and the coordinates of vector wfa share their transitions
+func verifyFullCertificate(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) bool {
+	if isScalarCertificate(leftWFA, rightWFA) && !acceptSet.hasConstraints() {
+		scalar, ok := scalarAcceptSet(acceptSet)
+		return ok && MITMWFARverifier(tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], scalar, -1)
+	}
+	return MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1)
+}
+
+//the accept set of a certificate with plain integer weights. Not ok if an entry has more than one coordinate or residues
+func scalarAcceptSet(vectorAcceptSet vectorAcceptSet) (acceptSet, bool) {
+	result := acceptSet{}
+	for config, polyhedron := range vectorAcceptSet {
+		if len(polyhedron.box) != 1 || polyhedron.box[0].residues != nil {
+			return nil, false
 		}
-		input.Scan()
-		leftWFA, err := parseWFA(input.Text())
-		if err != nil {
-			if input.Text() != "" {
-				fmt.Fprintln(os.Stderr, err)
-			}
-			continue
+		result[config] = polyhedron.box[0].bounds
+	}
+	return result, true
+}
+
+func (as vectorAcceptSet) hasConstraints() bool {
+	for _, polyhedron := range as {
+		if len(polyhedron.constraints) > 0 {
+			return true
 		}
-		input.Scan()
-		rightWFA, err := parseWFA(input.Text())
+	}
+	return false
+}
+
+func runSpecificValues(input *bufio.Scanner, workTokens chan struct{}, printMode, maxTransitions, maxLeftStates, maxRightStates, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions) {
+	for input.Scan() {
+		tm, err := parseTM(input.Text())
 		if err != nil {
 			if input.Text() != "" {
 				fmt.Fprintln(os.Stderr, err)
 			}
 			continue
 		}
-		leftSpecialSets := deriveSpecialSets(leftWFA)
-		rightSpecialSets := deriveSpecialSets(rightWFA)
-		acceptSet := findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets)
 		_ = <-workTokens
 		go func() {
-			MITMWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, printMode)
+			options := options.forTM()
+			solved := MITMWFARdecider(tm, maxTransitions, maxLeftStates, maxRightStates, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode)
+			options.statistics.report(tm, options.proofs.report() || solved)
 			workTokens <- struct{}{}
 		}()
 	}
 }
 
-func runSpecificValues(input *bufio.Scanner, workTokens chan struct{}, printMode, maxTransitions, maxLeftStates, maxRightStates, maxWeightPairs, addedMemory int) {
+//the memory depths a scan tries one after another up to the given ones: no memory first, then one more on a single side
+//at a time, ordered by total depth with the left side first. Without escalation only the given depths are tried
+func memoryEscalation(maxLeft, maxRight int, escalate bool) [][2]int {
+	if !escalate {
+		return [][2]int{{maxLeft, maxRight}}
+	}
+	result := [][2]int{}
+	for total := 0; total <= maxLeft+maxRight; total++ {
+		for left := total; left >= 0; left-- {
+			if left <= maxLeft && total-left <= maxRight {
+				result = append(result, [2]int{left, total - left})
+			}
+		}
+	}
+	return result
+}
+
+func runWeightedScan(input *bufio.Scanner, workTokens chan struct{}, printMode, maxTransitions, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, escalateMemory bool, options searchOptions) {
 	for input.Scan() {
 		tm, err := parseTM(input.Text())
 		if err != nil {
@@ -112,13 +220,21 @@ func runSpecificValues(input *bufio.Scanner, workTokens chan struct{}, printMode
 		}
 		_ = <-workTokens
 		go func() {
-			MITMWFARdecider(tm, maxTransitions, maxLeftStates, maxRightStates, maxWeightPairs, addedMemory, printMode)
+			options := options.forTM()
+			solved := false
+			memoryDepths := memoryEscalation(addedMemoryLeft, addedMemoryRight, escalateMemory)
+			for i := 0; i < len(memoryDepths) && !solved; i++ {
+				for transitions := 2; transitions <= maxTransitions && !solved; transitions++ {
+					solved = MITMWFARdecider(tm, transitions, maxTransitions, maxTransitions, maxWeightPairs, memoryDepths[i][0], memoryDepths[i][1], options, printMode)
+				}
+			}
+			options.statistics.report(tm, options.proofs.report() || solved)
 			workTokens <- struct{}{}
 		}()
 	}
 }
 
-func runWeightedScan(input *bufio.Scanner, workTokens chan struct{}, printMode, maxTransitions, maxWeightPairs, addedMemory int) {
+func runSATScan(input *bufio.Scanner, workTokens chan struct{}, printMode, maxStates, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, escalateMemory bool, options searchOptions, dimacs bool) {
 	for input.Scan() {
 		tm, err := parseTM(input.Text())
 		if err != nil {
@@ -129,10 +245,18 @@ func runWeightedScan(input *bufio.Scanner, workTokens chan struct{}, printMode,
 		}
 		_ = <-workTokens
 		go func() {
-			for transitions := 2; transitions <= maxTransitions; transitions++ {
-				if MITMWFARdecider(tm, transitions, maxTransitions, maxTransitions, maxWeightPairs, addedMemory, printMode) {
-					break
+			if dimacs {
+				fmt.Print(SATdimacs(tm, maxStates))
+			} else {
+				options := options.forTM()
+				solved := false
+				for _, memory := range memoryEscalation(addedMemoryLeft, addedMemoryRight, escalateMemory) {
+					if SATdecider(tm, maxStates, maxWeightPairs, memory[0], memory[1], options, printMode) {
+						solved = true
+						break
+					}
 				}
+				options.statistics.report(tm, options.proofs.report() || solved)
 			}
 			workTokens <- struct{}{}
 		}()
@@ -152,7 +276,7 @@ func runDFAScan(input *bufio.Scanner, workTokens chan struct{}, printMode, maxSt
 		go func() {
 			maxTransitions := tm.symbols * (maxStates - 1) * 2
 			for transitions := 2; transitions <= maxTransitions; transitions++ {
-				if MITMWFARdecider(tm, transitions, maxStates, maxStates, 0, 0, printMode) {
+				if MITMWFARdecider(tm, transitions, maxStates, maxStates, 0, 0, 0, searchOptions{}, printMode) {
 					break
 				}
 			}
@@ -243,13 +367,119 @@ func parseWFA(s string) (wfa dwfa, err error) {
 	return
 }
 
-//"0,1,4,5_0,2"
+//"0,0,0;1,0,1_1,1,0;0,0,0" with one weight per coordinate, optionally followed by the moduli "%0,2"
+func parseVectorWFA(s string) (wfa vectorWFA, err error) {
+	defer func() {
+		if recover() != nil {
+			err = errorString("Couldn't parse WFA: \"" + s + "\"")
+		}
+	}()
+	transitionString, moduliString, hasModuli := strings.Cut(s, "%")
+	stateStrings := strings.Split(transitionString, "_")
+	dimensions := len(strings.Split(strings.Split(stateStrings[0], ";")[0], ",")) - 1
+	if dimensions < 1 {
+		panic("")
+	}
+	for k := 0; k < dimensions; k++ {
+		wfa = append(wfa, dwfa{
+			states:      len(stateStrings),
+			startState:  0,
+			transitions: map[wfaState]map[symbol]wfaTransition{},
+		})
+	}
+	for i, stateString := range stateStrings {
+		symbolStrings := strings.Split(stateString, ";")
+		for k := range wfa {
+			wfa[k].symbols = len(symbolStrings)
+			wfa[k].transitions[wfaState(i)] = map[symbol]wfaTransition{}
+		}
+		for j, symbolString := range symbolStrings {
+			values := strings.Split(symbolString, ",")
+			if len(values) != dimensions+1 {
+				panic("")
+			}
+			targetState, _ := strconv.Atoi(values[0])
+			for k := range wfa {
+				addedWeight, _ := strconv.Atoi(values[k+1])
+				wfa[k].transitions[wfaState(i)][symbol(j)] = wfaTransition{
+					wfaState(targetState),
+					weight(addedWeight),
+				}
+			}
+		}
+	}
+	if hasModuli {
+		moduli, err := parseModuli(moduliString)
+		if err != nil || len(moduli) != dimensions {
+			panic("")
+		}
+		for k := range wfa {
+			wfa[k].modulus = moduli[k]
+		}
+	}
+	return
+}
+
+//"0,2"
+func parseModuli(s string) (moduli []weight, err error) {
+	defer func() {
+		if recover() != nil {
+			err = errorString("Couldn't parse moduli: \"" + s + "\"")
+		}
+	}()
+	for _, modulusString := range strings.Split(s, ",") {
+		modulus, err := strconv.Atoi(modulusString)
+		if err != nil || modulus < 0 {
+			panic("")
+		}
+		moduli = append(moduli, weight(modulus))
+	}
+	return
+}
+
+//special sets of each coordinate separated by "|"
+func parseVectorSpecialSets(s string) (sets vectorSpecialSets, err error) {
+	for _, coordinateString := range strings.Split(s, "|") {
+		coordinateSets, err := parseSpecialSets(coordinateString)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, coordinateSets)
+	}
+	return
+}
+
+//"0,1,4,5_0,2" or "0:0,0_1:-3,-_2:0,2" or for modular wfa "0%0_1%1,3"
 func parseSpecialSets(s string) (sets specialSets, err error) {
 	defer func() {
 		if recover() != nil {
 			err = errorString("Couldn't parse special sets: \"" + s + "\"")
 		}
 	}()
+	if strings.Contains(s, "%") {
+		sets = specialSets{residues: map[wfaState]set[weight]{}}
+		for _, residueString := range strings.Split(s, "_") {
+			values := strings.Split(residueString, "%")
+			state, err := strconv.Atoi(values[0])
+			if err != nil {
+				panic("")
+			}
+			sets.residues[wfaState(state)] = parseResidues(strings.Split(values[1], ","))
+		}
+		return
+	}
+	if strings.Contains(s, ":") {
+		sets = specialSets{intervals: map[wfaState]bounds{}}
+		for _, intervalString := range strings.Split(s, "_") {
+			values := strings.Split(intervalString, ":")
+			state, err := strconv.Atoi(values[0])
+			if err != nil {
+				panic("")
+			}
+			sets.intervals[wfaState(state)] = parseBounds(strings.Split(values[1], ","))
+		}
+		return
+	}
 	setStrings := strings.Split(s, "_")
 	sets = specialSets{
 		nonNegative: parseStateSet(setStrings[0]),
@@ -270,31 +500,144 @@ func parseStateSet(s string) set[wfaState] {
 	return set
 }
 
-//"A,0,0,0,-,-_B,1,0,2,2,-"
-func parseAcceptSet(s string) (set acceptSet, err error) {
+//"A,0,0,0,-,-,0,0_B,1,0,2,2,-,%1;3&w1-w2>=0" with lower and upper bound of each coordinate, or the residues for modular coordinates,
+//optionally followed by linear constraints each introduced by "&". The bounds of a coordinate can be followed by a congruence "1mod2"
+//and gaps "~5;8"
+func parseVectorAcceptSet(s string) (set vectorAcceptSet, err error) {
 	defer func() {
 		if recover() != nil {
 			err = errorString("Couldn't parse accept set: \"" + s + "\"")
 		}
 	}()
-	set = acceptSet{}
+	set = vectorAcceptSet{}
 	for _, accepter := range strings.Split(s, "_") {
-		values := strings.Split(accepter, ",")
+		constraintStrings := strings.Split(accepter, "&")
+		values := strings.Split(constraintStrings[0], ",")
+		if len(values) < 5 {
+			panic("")
+		}
 		newTMState := tmState(values[0][0] - 'A')
 		newSymbol := symbol(values[1][0] - '0')
 		leftState, _ := strconv.Atoi(values[2])
 		rightState, _ := strconv.Atoi(values[3])
 		newConfig := config{newTMState, newSymbol, wfaState(leftState), wfaState(rightState)}
-		newBounds := map[boundType]weight{}
-		lowerbound, lowerExists := strconv.Atoi(values[4])
-		if lowerExists == nil {
-			newBounds[LOWER] = weight(lowerbound)
+		newBox := box{}
+		for k := 4; k < len(values); k += 2 {
+			if strings.HasPrefix(values[k], "%") {
+				newBox = append(newBox, coordinateBounds{residues: parseResidues(strings.Split(values[k][1:], ";"))})
+				k -= 1
+				continue
+			}
+			newBounds := parseBounds(values[k : k+2])
+			k += parseBoundsExtensions(values[k+2:], newBounds)
+			newBox = append(newBox, coordinateBounds{bounds: newBounds})
 		}
-		upperbound, upperExists := strconv.Atoi(values[5])
-		if upperExists == nil {
-			newBounds[UPPER] = weight(upperbound)
+		newPolyhedron := polyhedron{box: newBox}
+		for _, constraintString := range constraintStrings[1:] {
+			newPolyhedron.constraints = append(newPolyhedron.constraints, parseLinearConstraint(constraintString, len(newBox)))
 		}
-		set[newConfig] = newBounds
+		set[newConfig] = newPolyhedron
 	}
 	return
 }
+
+//"2*w1-w2>=0", "w1+w3<=5" or "-2<=w1-w2<=2"
+func parseLinearConstraint(s string, dimensions int) linearConstraint {
+	constraint := linearConstraint{bounds: bounds{}}
+	var expression string
+	if parts := strings.Split(s, "<="); len(parts) == 3 {
+		constraint.bounds = parseBounds([]string{parts[0], parts[2]})
+		expression = parts[1]
+	} else if len(parts) == 2 {
+		constraint.bounds = parseBounds([]string{"-", parts[1]})
+		expression = parts[0]
+	} else if parts := strings.Split(s, ">="); len(parts) == 2 {
+		constraint.bounds = parseBounds([]string{parts[1], "-"})
+		expression = parts[0]
+	} else {
+		panic("")
+	}
+	constraint.coefficients = make(weightVector, dimensions)
+	for _, term := range strings.Split(strings.ReplaceAll(expression, "-", "+-"), "+") {
+		if term == "" || term == "0" {
+			continue
+		}
+		coefficientString, variableString, hasCoefficient := strings.Cut(term, "*")
+		if !hasCoefficient {
+			coefficientString = strings.TrimSuffix(term, strings.TrimPrefix(term, "-")) + "1"
+			variableString = strings.TrimPrefix(term, "-")
+		}
+		coefficient, err := strconv.Atoi(coefficientString)
+		if err != nil || !strings.HasPrefix(variableString, "w") {
+			panic("")
+		}
+		coordinate, err := strconv.Atoi(variableString[1:])
+		if err != nil {
+			panic("")
+		}
+		constraint.coefficients[coordinate-1] += weight(coefficient)
+	}
+	return constraint
+}
+
+//"0","2"
+func parseResidues(values []string) set[weight] {
+	residues := set[weight]{}
+	for _, value := range values {
+		residue, err := strconv.Atoi(value)
+		if err != nil {
+			panic("")
+		}
+		residues.add(weight(residue))
+	}
+	return residues
+}
+
+//parses the congruence "1mod2" and the gaps "~5;8" that can follow the bounds and adds them to the bounds.
+//returns the number of values used
+func parseBoundsExtensions(values []string, bounds bounds) int {
+	used := 0
+	if used < len(values) && strings.Contains(values[used], "mod") {
+		parseCongruence(values[used], bounds)
+		used += 1
+	}
+	gaps := []gap{}
+	for used < len(values) && strings.HasPrefix(values[used], "~") {
+		ends := strings.Split(values[used][1:], ";")
+		lower, lowerErr := strconv.Atoi(ends[0])
+		upper, upperErr := strconv.Atoi(ends[1])
+		if len(ends) != 2 || lowerErr != nil || upperErr != nil {
+			panic("")
+		}
+		gaps = append(gaps, gap{weight(lower), weight(upper)})
+		used += 1
+	}
+	bounds.setGaps(gaps)
+	return used
+}
+
+//"1mod2", adds the congruence to the bounds
+func parseCongruence(s string, bounds bounds) {
+	residueString, modulusString, ok := strings.Cut(s, "mod")
+	residue, residueErr := strconv.Atoi(residueString)
+	modulus, modulusErr := strconv.Atoi(modulusString)
+	if !ok || residueErr != nil || modulusErr != nil {
+		panic("")
+	}
+	bounds[MODULUS] = weight(modulus)
+	bounds[RESIDUE] = weight(residue)
+}
+
+//"-3","-"
+func parseBounds(values []string) bounds {
+	newBounds := bounds{}
+	lowerbound, lowerExists := strconv.Atoi(values[0])
+	if lowerExists == nil {
+		newBounds[LOWER] = weight(lowerbound)
+	}
+	upperbound, upperExists := strconv.Atoi(values[1])
+	if upperExists == nil {
+		newBounds[UPPER] = weight(upperbound)
+	}
+	return newBounds
+}
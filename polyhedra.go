@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//bounds on a linear combination of the coordinates of the weight sum.
+//"2*w1-w2>=0" is {coefficients: weightVector{2, -1}, bounds: bounds{LOWER: 0}}
+type linearConstraint struct {
+	coefficients weightVector
+	bounds       bounds
+}
+
+//the accepted weight sums of a config: a box that is cut down further by linear constraints.
+//modular coordinates never appear in the constraints.
+type polyhedron struct {
+	box
+	constraints []linearConstraint
+}
+
+func dotProduct(a, b weightVector) weight {
+	result := weight(0)
+	for i := range a {
+		product := a[i] * b[i]
+		check(product)
+		result += product
+		check(result)
+	}
+	return result
+}
+
+//the directions w_i+w_j and w_i-w_j for every pair of integer coordinates
+func octagonTemplates(moduli []weight) []weightVector {
+	result := []weightVector{}
+	for i := range moduli {
+		for j := i + 1; j < len(moduli); j++ {
+			if moduli[i] > 0 || moduli[j] > 0 {
+				continue
+			}
+			for _, sign := range []weight{1, -1} {
+				coefficients := make(weightVector, len(moduli))
+				coefficients[i] = 1
+				coefficients[j] = sign
+				result = append(result, coefficients)
+			}
+		}
+	}
+	return result
+}
+
+//the bounds of the linear combination over all weight sums in the box
+func boundsOfLinearCombination(box box, coefficients weightVector) bounds {
+	result := bounds{LOWER: 0, UPPER: 0}
+	for i, coefficient := range coefficients {
+		if coefficient == 0 {
+			continue
+		}
+		//a negative coefficient turns the lower bound of the coordinate into an upper bound of the sum
+		coordinateBounds := map[boundType]boundType{LOWER: LOWER, UPPER: UPPER}
+		if coefficient < 0 {
+			coordinateBounds = map[boundType]boundType{LOWER: UPPER, UPPER: LOWER}
+		}
+		for _, bound := range []boundType{LOWER, UPPER} {
+			value, ok := box[i].bounds[coordinateBounds[bound]]
+			if _, stillBounded := result[bound]; !ok || !stillBounded {
+				delete(result, bound)
+				continue
+			}
+			check(value * coefficient)
+			result[bound] += value * coefficient
+			check(result[bound])
+		}
+	}
+	return result
+}
+
+func shiftConstraints(constraints []linearConstraint, change weightVector) []linearConstraint {
+	result := []linearConstraint{}
+	for _, constraint := range constraints {
+		result = append(result, linearConstraint{constraint.coefficients, shiftBounds(constraint.bounds, dotProduct(constraint.coefficients, change))})
+	}
+	return result
+}
+
+//shifts the constraints by the change and clips each of them to the bounds the hard box implies.
+//returns false if one of the constraints can't be satisfied anymore
+func shiftAndClipConstraints(constraints []linearConstraint, change weightVector, hardBox box) ([]linearConstraint, bool) {
+	result := []linearConstraint{}
+	for _, constraint := range shiftConstraints(constraints, change) {
+		nextBounds, nonEmpty := clipBounds(constraint.bounds, boundsOfLinearCombination(hardBox, constraint.coefficients))
+		if !nonEmpty {
+			return nil, false
+		}
+		result = append(result, linearConstraint{constraint.coefficients, nextBounds})
+	}
+	return result, true
+}
+
+//widens the accepted constraints in place to include the next ones, see joinBounds.
+//both have to come from the same templates
+func joinConstraints(acceptConstraints, nextConstraints []linearConstraint, hardBox box) bool {
+	change := false
+	for i := range acceptConstraints {
+		hardBounds := boundsOfLinearCombination(hardBox, acceptConstraints[i].coefficients)
+		if joinBounds(acceptConstraints[i].bounds, nextConstraints[i].bounds, hardBounds, 1) {
+			change = true
+		}
+	}
+	return change
+}
+
+func polyhedronIncludesPolyhedron(outer, inner polyhedron, moduli []weight) bool {
+	if len(outer.constraints) == 0 && len(inner.constraints) == 0 {
+		return boxIncludesBox(outer.box, inner.box, moduli)
+	}
+	premises := []halfSpace{}
+	for _, constraint := range append(boxConstraints(inner.box, moduli), inner.constraints...) {
+		premises = append(premises, halfSpaces(constraint)...)
+	}
+	if !feasible(premises, len(moduli)) {
+		return true
+	}
+	for i := range outer.box {
+		if moduli[i] > 0 {
+			for residue := range inner.box[i].residues {
+				if !outer.box[i].residues.contains(residue) {
+					return false
+				}
+			}
+			continue
+		}
+		if !congruenceIncludes(outer.box[i].bounds, inner.box[i].bounds) || !gapsInclude(outer.box[i].bounds, inner.box[i].bounds) {
+			return false
+		}
+	}
+	for _, constraint := range append(boxConstraints(outer.box, moduli), outer.constraints...) {
+		for _, conclusion := range halfSpaces(constraint) {
+			if !entails(premises, conclusion, len(moduli)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//whether the polyhedron accepts the weight sum. Modular coordinates of the weight sum have to be reduced
+func polyhedronContains(p polyhedron, weights weightVector) bool {
+	for i, coordinateBounds := range p.box {
+		if coordinateBounds.residues != nil && !coordinateBounds.residues.contains(weights[i]) {
+			return false
+		}
+		if !boundsContain(coordinateBounds.bounds, weights[i]) {
+			return false
+		}
+	}
+	for _, constraint := range p.constraints {
+		if !boundsContain(constraint.bounds, dotProduct(constraint.coefficients, weights)) {
+			return false
+		}
+	}
+	return true
+}
+
+//the bounds of each integer coordinate as constraints
+func boxConstraints(box box, moduli []weight) []linearConstraint {
+	result := []linearConstraint{}
+	for i := range box {
+		if moduli[i] > 0 || len(box[i].bounds) == 0 {
+			continue
+		}
+		coefficients := make(weightVector, len(box))
+		coefficients[i] = 1
+		result = append(result, linearConstraint{coefficients, box[i].bounds})
+	}
+	return result
+}
+
+//------------------------------------------------------------------------------------------------
+
+//coefficients·w >= bound over the rationals
+type halfSpace struct {
+	coefficients []*big.Rat
+	bound        *big.Rat
+}
+
+//splits the constraint into its lower and upper half. Since the weights are integers the
+//coefficients can be divided by their gcd and the bound rounded up, which makes the halfSpaces tighter.
+func halfSpaces(constraint linearConstraint) []halfSpace {
+	result := []halfSpace{}
+	for _, bound := range []boundType{LOWER, UPPER} {
+		value, ok := constraint.bounds[bound]
+		if !ok {
+			continue
+		}
+		sign := weight(1)
+		if bound == UPPER {
+			sign = -1
+		}
+		divisor := weight(0)
+		for _, coefficient := range constraint.coefficients {
+			divisor = gcd(divisor, coefficient)
+		}
+		if divisor == 0 {
+			divisor = 1
+		}
+		newHalfSpace := halfSpace{bound: big.NewRat(int64(ceilDiv(sign*value, divisor)), 1)}
+		for _, coefficient := range constraint.coefficients {
+			newHalfSpace.coefficients = append(newHalfSpace.coefficients, big.NewRat(int64(sign*coefficient/divisor), 1))
+		}
+		result = append(result, newHalfSpace)
+	}
+	return result
+}
+
+func gcd(a, b weight) weight {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+//rounds towards positive infinity, divisor has to be positive
+func ceilDiv(value, divisor weight) weight {
+	result := value / divisor
+	if value%divisor != 0 && value > 0 {
+		result += 1
+	}
+	return result
+}
+
+//whether every integer point of the premises satisfies the conclusion.
+//coefficients·w >= bound holds iff coefficients·w <= bound-1 is infeasible, because both sides are integers.
+func entails(premises []halfSpace, conclusion halfSpace, dimensions int) bool {
+	for _, premise := range premises {
+		if sameCoefficients(premise, conclusion) && premise.bound.Cmp(conclusion.bound) >= 0 {
+			return true
+		}
+	}
+	negation := halfSpace{bound: new(big.Rat).Sub(big.NewRat(1, 1), conclusion.bound)}
+	for _, coefficient := range conclusion.coefficients {
+		negation.coefficients = append(negation.coefficients, new(big.Rat).Neg(coefficient))
+	}
+	return !feasible(append(append([]halfSpace{}, premises...), negation), dimensions)
+}
+
+func sameCoefficients(a, b halfSpace) bool {
+	for i := range a.coefficients {
+		if a.coefficients[i].Cmp(b.coefficients[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//Fourier–Motzkin elimination over the rationals: removes one variable after the other by combining every
+//lower bound on it with every upper bound. The halfSpaces are feasible iff no contradiction 0 >= bound > 0 is left.
+//this is exact for rational points, so an infeasible answer also holds for integer points.
+func feasible(halfSpaces []halfSpace, dimensions int) bool {
+	rows, ok := normalizeHalfSpaces(halfSpaces)
+	if !ok {
+		return false
+	}
+	for variable := 0; variable < dimensions; variable++ {
+		lower := []halfSpace{}
+		upper := []halfSpace{}
+		next := []halfSpace{}
+		for _, row := range rows {
+			switch row.coefficients[variable].Sign() {
+			case 1:
+				lower = append(lower, row)
+			case -1:
+				upper = append(upper, row)
+			default:
+				next = append(next, row)
+			}
+		}
+		for _, lowerRow := range lower {
+			for _, upperRow := range upper {
+				//both rows are normalized so that the variable has coefficient 1 and -1, so they can just be added
+				combined := halfSpace{bound: new(big.Rat).Add(lowerRow.bound, upperRow.bound)}
+				for i := range lowerRow.coefficients {
+					combined.coefficients = append(combined.coefficients, new(big.Rat).Add(lowerRow.coefficients[i], upperRow.coefficients[i]))
+				}
+				next = append(next, combined)
+			}
+		}
+		rows, ok = normalizeHalfSpaces(next)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//scales every row so that its first nonzero coefficient is 1 or -1 and only keeps the tightest bound for each direction.
+//rows without any nonzero coefficient are dropped, returns false if one of them is a contradiction
+func normalizeHalfSpaces(halfSpaces []halfSpace) ([]halfSpace, bool) {
+	tightest := map[string]halfSpace{}
+	order := []string{}
+	for _, row := range halfSpaces {
+		pivot := -1
+		for i, coefficient := range row.coefficients {
+			if coefficient.Sign() != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			if row.bound.Sign() > 0 {
+				return nil, false
+			}
+			continue
+		}
+		scale := new(big.Rat).Abs(row.coefficients[pivot])
+		scale.Inv(scale)
+		normalized := halfSpace{bound: new(big.Rat).Mul(row.bound, scale)}
+		key := ""
+		for _, coefficient := range row.coefficients {
+			scaled := new(big.Rat).Mul(coefficient, scale)
+			normalized.coefficients = append(normalized.coefficients, scaled)
+			key += scaled.RatString() + ","
+		}
+		if old, ok := tightest[key]; ok {
+			if old.bound.Cmp(normalized.bound) < 0 {
+				tightest[key] = normalized
+			}
+			continue
+		}
+		tightest[key] = normalized
+		order = append(order, key)
+	}
+	result := []halfSpace{}
+	for _, key := range order {
+		result = append(result, tightest[key])
+	}
+	return result, true
+}
+
+//------------------------------------------------------------------------------------------------
+
+func (p polyhedron) String() string {
+	result := p.box.String()
+	for _, constraint := range p.constraints {
+		if len(constraint.bounds) > 0 {
+			result += "&" + constraint.String()
+		}
+	}
+	return result
+}
+
+//"2*w1-w2>=0", "w1+w3<=5" or "-2<=w1-w2<=2". coordinates are counted from 1
+func (c linearConstraint) String() string {
+	expression := ""
+	for i, coefficient := range c.coefficients {
+		switch {
+		case coefficient == 0:
+			continue
+		case coefficient == 1:
+			expression += "+"
+		case coefficient == -1:
+			expression += "-"
+		case coefficient > 0:
+			expression += fmt.Sprintf("+%v*", coefficient)
+		default:
+			expression += fmt.Sprintf("%v*", coefficient)
+		}
+		expression += "w" + strconv.Itoa(i+1)
+	}
+	expression = strings.TrimPrefix(expression, "+")
+	if expression == "" {
+		expression = "0"
+	}
+	lowerbound, lowerExists := c.bounds[LOWER]
+	upperbound, upperExists := c.bounds[UPPER]
+	switch {
+	case lowerExists && upperExists:
+		return fmt.Sprintf("%v<=%v<=%v", lowerbound, expression, upperbound)
+	case lowerExists:
+		return fmt.Sprintf("%v>=%v", expression, lowerbound)
+	case upperExists:
+		return fmt.Sprintf("%v<=%v", expression, upperbound)
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolyhedronIncludesPolyhedron(t *testing.T) {
+	moduli := []weight{0, 0}
+	t.Run("ViaConstraint", func(t *testing.T) {
+		inner := polyhedron{
+			box{{bounds: bounds{LOWER: 0}}, {bounds: bounds{}}},
+			[]linearConstraint{{weightVector{1, -1}, bounds{LOWER: 0, UPPER: 0}}},
+		}
+		outer := polyhedron{box: box{{bounds: bounds{}}, {bounds: bounds{LOWER: 0}}}}
+		if !polyhedronIncludesPolyhedron(outer, inner, moduli) {
+			t.Fail()
+		}
+		outer.box[1].bounds[LOWER] = 1
+		if polyhedronIncludesPolyhedron(outer, inner, moduli) {
+			t.Fail()
+		}
+	})
+	t.Run("IntegerRounding", func(t *testing.T) {
+		inner := polyhedron{
+			box{{bounds: bounds{}}, {bounds: bounds{}}},
+			[]linearConstraint{{weightVector{2, 0}, bounds{LOWER: 1}}},
+		}
+		outer := polyhedron{box: box{{bounds: bounds{LOWER: 1}}, {bounds: bounds{}}}}
+		if !polyhedronIncludesPolyhedron(outer, inner, moduli) {
+			t.Fail()
+		}
+	})
+	t.Run("EmptyInner", func(t *testing.T) {
+		inner := polyhedron{
+			box{{bounds: bounds{LOWER: 0, UPPER: 1}}, {bounds: bounds{LOWER: 0, UPPER: 1}}},
+			[]linearConstraint{{weightVector{1, 1}, bounds{LOWER: 3}}},
+		}
+		outer := polyhedron{box: box{{bounds: bounds{LOWER: 5}}, {bounds: bounds{}}}}
+		if !polyhedronIncludesPolyhedron(outer, inner, moduli) {
+			t.Fail()
+		}
+	})
+}
+
+func TestFeasible(t *testing.T) {
+	constraints := []linearConstraint{
+		{weightVector{1, 0, 0}, bounds{LOWER: 0}},
+		{weightVector{-1, 1, 0}, bounds{LOWER: 0}},
+		{weightVector{0, -1, 1}, bounds{LOWER: 0}},
+		{weightVector{0, 0, 1}, bounds{UPPER: 2}},
+	}
+	halfSpaceList := []halfSpace{}
+	for _, constraint := range constraints {
+		halfSpaceList = append(halfSpaceList, halfSpaces(constraint)...)
+	}
+	if !feasible(halfSpaceList, 3) {
+		t.Fail()
+	}
+	halfSpaceList = append(halfSpaceList, halfSpaces(linearConstraint{weightVector{1, 0, 0}, bounds{LOWER: 3}})...)
+	if feasible(halfSpaceList, 3) {
+		t.Fail()
+	}
+}
+
+func TestParseLinearConstraint(t *testing.T) {
+	for _, s := range []string{"2*w1-w2>=0", "w1+w3<=5", "-2<=-w2-3*w3<=2"} {
+		constraint := parseLinearConstraint(s, 3)
+		if constraint.String() != s {
+			t.Fail()
+		}
+	}
+	expectedConstraint := linearConstraint{weightVector{2, -1, 0}, bounds{LOWER: 0}}
+	if !reflect.DeepEqual(expectedConstraint, parseLinearConstraint("2*w1-w2>=0", 3)) {
+		t.Fail()
+	}
+}
+
+func TestPolyhedralAcceptSet(t *testing.T) {
+	tm := exampleVectorTM()
+	leftWFA, rightWFA := exampleVectorWFAs()
+	leftWFA[1].transitions[0][1] = wfaTransition{0, 1}
+	rightWFA[1].transitions[1][1] = wfaTransition{1, 1}
+	leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+	rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+	acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, octagonTemplates(leftWFA.moduli()))
+	if !acceptSet.hasConstraints() {
+		t.FailNow()
+	}
+	if !MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		t.Fail()
+	}
+	parsedAcceptSet, err := parseVectorAcceptSet(acceptSet.String())
+	if err != nil || !MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, parsedAcceptSet, -1) {
+		t.Fail()
+	}
+	//the first coordinate is a copy of the second one, so their difference has to stay 0
+	for _, polyhedron := range acceptSet {
+		if !reflect.DeepEqual(polyhedron.constraints[1].bounds, bounds{LOWER: 0, UPPER: 0}) {
+			t.Fail()
+		}
+		polyhedron.constraints[1].bounds[LOWER] = 1
+	}
+	if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+//a verified certificate found while collecting all proofs of a TM
+type proof struct {
+	certificate string
+	//the size the proofs are ranked by: states of both wfa, then accept set entries, then weighted transitions
+	states, acceptSetSize, weightedTransitions int
+}
+
+//the distinct proofs of a single TM, keyed by the minimal forms of their wfa pair.
+//all methods treat nil like a search that stops at the first proof
+type proofCollection struct {
+	maxProofs int
+	proofs    map[string]proof
+}
+
+func weightedTransitions(wfa vectorWFA) int {
+	result := 0
+	for state, transitions := range wfa[0].transitions {
+		for symbol := range transitions {
+			for _, coordinate := range wfa {
+				if coordinate.transitions[state][symbol].weight != 0 {
+					result += 1
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+//records a certificate that passed the verifier and the simulation check. Without a collection it is printed right away
+//and the search stops. Otherwise it is only printed once the search is over and the search goes on
+func (c *proofCollection) add(printMode int, tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) bool {
+	if c == nil {
+		if printMode >= 0 {
+			fmt.Print(certificateString(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet))
+		}
+		return true
+	}
+	c.addProof(certificateString(2, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet), vectorWFA{leftWFA}, vectorWFA{rightWFA}, len(acceptSet))
+	return false
+}
+
+func (c *proofCollection) addVector(printMode int, tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) bool {
+	if c == nil {
+		if printMode >= 0 {
+			fmt.Print(certificateString(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet))
+		}
+		return true
+	}
+	c.addProof(certificateString(2, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet), leftWFA, rightWFA, len(acceptSet))
+	return false
+}
+
+func (c *proofCollection) addProof(certificate string, leftWFA, rightWFA vectorWFA, acceptSetSize int) {
+	key := minimalForm(leftWFA) + "|" + minimalForm(rightWFA)
+	newProof := proof{certificate, leftWFA[0].states + rightWFA[0].states, acceptSetSize, weightedTransitions(leftWFA) + weightedTransitions(rightWFA)}
+	if oldProof, ok := c.proofs[key]; !ok || newProof.smallerThan(oldProof) {
+		c.proofs[key] = newProof
+	}
+}
+
+func (p proof) smallerThan(other proof) bool {
+	if p.states != other.states {
+		return p.states < other.states
+	}
+	if p.acceptSetSize != other.acceptSetSize {
+		return p.acceptSetSize < other.acceptSetSize
+	}
+	if p.weightedTransitions != other.weightedTransitions {
+		return p.weightedTransitions < other.weightedTransitions
+	}
+	return p.certificate < other.certificate
+}
+
+//prints up to maxProofs of the collected proofs as full certificates, smallest first. Returns whether there were any
+func (c *proofCollection) report() bool {
+	if c == nil {
+		return false
+	}
+	proofs := []proof{}
+	for _, proof := range c.proofs {
+		proofs = append(proofs, proof)
+	}
+	sort.Slice(proofs, func(i, j int) bool { return proofs[i].smallerThan(proofs[j]) })
+	if len(proofs) > c.maxProofs {
+		proofs = proofs[:c.maxProofs]
+	}
+	result := ""
+	for _, proof := range proofs {
+		result += proof.certificate
+	}
+	fmt.Print(result)
+	return len(proofs) > 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+	"testing"
+)
+
+//every collected proof has to pass the verifier, and no two of them may only differ up to the canonical pair
+func checkProofs(t *testing.T, proofs *proofCollection) {
+	t.Helper()
+	canonicalPairs := set[string]{}
+	for key, proof := range proofs.proofs {
+		fullCertificate := bufio.NewScanner(strings.NewReader(proof.certificate))
+		fullCertificate.Scan()
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(fullCertificate)
+		if err != nil || !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+			t.Error(proof.certificate)
+			continue
+		}
+		pair := canonicalPair(leftWFA, rightWFA)
+		if canonicalPairs.contains(pair) {
+			t.Error(key, pair)
+		}
+		canonicalPairs.add(pair)
+	}
+}
+
+func TestProofCollection(t *testing.T) {
+	tm, _ := parseTM("1RB1RA_0LA---")
+	options := searchOptions{allProofs: 10}.forTM()
+	for transitions := 2; transitions <= 4; transitions++ {
+		if MITMWFARdecider(tm, transitions, 9, 9, 0, 0, 0, options, -1) {
+			t.FailNow()
+		}
+	}
+	keys := []string{}
+	for key := range options.proofs.proofs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return options.proofs.proofs[keys[i]].smallerThan(options.proofs.proofs[keys[j]]) })
+	expected := []struct {
+		key                   string
+		states, acceptSetSize int
+	}{
+		{"0,0;0,0|0,0;1,0_1,0;1,0", 4, 3},
+		{"0,0;1,0_2,0;1,0_2,0;2,0|0,0;1,0_1,0;1,0", 5, 5},
+		{"0,0;1,0_2,0;0,0_2,0;2,0|0,0;1,0_1,0;1,0", 5, 6},
+	}
+	if len(keys) != len(expected) {
+		t.Fatal(keys)
+	}
+	for i, key := range keys {
+		proof := options.proofs.proofs[key]
+		if key != expected[i].key || proof.states != expected[i].states || proof.acceptSetSize != expected[i].acceptSetSize || proof.weightedTransitions != 0 {
+			t.Error(i, key, proof.states, proof.acceptSetSize, proof.weightedTransitions)
+		}
+	}
+	//without a collection the search stops at the first proof
+	if (*proofCollection)(nil).add(-1, tm, dwfa{}, dwfa{}, specialSets{}, specialSets{}, acceptSet{}) != true {
+		t.Fail()
+	}
+}
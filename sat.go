@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+//a variable number that is negated for the negative literal, like in DIMACS. Variables start at 1
+type literal int
+
+func (l literal) variable() int {
+	if l < 0 {
+		return int(-l)
+	}
+	return int(l)
+}
+
+//position of the literal in the watch lists
+func (l literal) index() int {
+	if l < 0 {
+		return 2*int(-l) + 1
+	}
+	return 2 * int(l)
+}
+
+//a CDCL SAT solver: two watched literals for unit propagation, clauses learned from the first unique implication
+//point of each conflict, variable activities for branching, phase saving and luby restarts.
+//clauses can be added between calls of solve, e.g. to block a model
+type satSolver struct {
+	variables int
+	//the clauses as they were added, for writing them as DIMACS
+	problem    [][]literal
+	clauses    [][]literal
+	watches    [][]int
+	assignment []int8
+	level      []int
+	//clause that implied the assignment of each variable, -1 for decisions
+	reason        []int
+	trail         []literal
+	trailLimits   []int
+	propagated    int
+	activity      []float64
+	increment     float64
+	phase         []int8
+	model         []bool
+	unsatisfiable bool
+}
+
+func newSATSolver() *satSolver {
+	return &satSolver{
+		watches:    [][]int{nil, nil},
+		assignment: []int8{0},
+		level:      []int{0},
+		reason:     []int{-1},
+		activity:   []float64{0},
+		phase:      []int8{-1},
+		increment:  1,
+	}
+}
+
+func (s *satSolver) newVariable() literal {
+	s.variables += 1
+	s.watches = append(s.watches, nil, nil)
+	s.assignment = append(s.assignment, 0)
+	s.level = append(s.level, 0)
+	s.reason = append(s.reason, -1)
+	s.activity = append(s.activity, 0)
+	s.phase = append(s.phase, -1)
+	return literal(s.variables)
+}
+
+//1 if the literal is true, -1 if it is false and 0 if its variable is unassigned
+func (s *satSolver) value(l literal) int8 {
+	if l < 0 {
+		return -s.assignment[-l]
+	}
+	return s.assignment[l]
+}
+
+//the value of the variable in the last model solve found
+func (s *satSolver) modelValue(l literal) bool {
+	return s.model[l.variable()] == (l > 0)
+}
+
+func (s *satSolver) addClause(literals ...literal) {
+	s.problem = append(s.problem, append([]literal{}, literals...))
+	s.backtrack(0)
+	//literals that are already false can be dropped and satisfied clauses are not needed at all
+	clause := []literal{}
+	for _, l := range literals {
+		switch s.value(l) {
+		case 1:
+			return
+		case 0:
+			duplicate := false
+			for _, other := range clause {
+				if other == -l {
+					return
+				}
+				duplicate = duplicate || other == l
+			}
+			if !duplicate {
+				clause = append(clause, l)
+			}
+		}
+	}
+	switch len(clause) {
+	case 0:
+		s.unsatisfiable = true
+	case 1:
+		s.enqueue(clause[0], -1)
+		if s.propagate() >= 0 {
+			s.unsatisfiable = true
+		}
+	default:
+		s.attach(clause)
+	}
+}
+
+func (s *satSolver) attach(clause []literal) int {
+	s.clauses = append(s.clauses, clause)
+	index := len(s.clauses) - 1
+	s.watches[clause[0].index()] = append(s.watches[clause[0].index()], index)
+	s.watches[clause[1].index()] = append(s.watches[clause[1].index()], index)
+	return index
+}
+
+func (s *satSolver) enqueue(l literal, reason int) {
+	s.assignment[l.variable()] = 1
+	if l < 0 {
+		s.assignment[l.variable()] = -1
+	}
+	s.level[l.variable()] = len(s.trailLimits)
+	s.reason[l.variable()] = reason
+	s.trail = append(s.trail, l)
+}
+
+//assigns all unit literals. Returns the index of a conflicting clause or -1.
+//the clauses watch their first two literals, the implied literal of a clause is always its first one
+func (s *satSolver) propagate() int {
+	for s.propagated < len(s.trail) {
+		falseLiteral := -s.trail[s.propagated]
+		s.propagated += 1
+		watchList := s.watches[falseLiteral.index()]
+		kept := watchList[:0]
+		for i := 0; i < len(watchList); i++ {
+			clauseIndex := watchList[i]
+			clause := s.clauses[clauseIndex]
+			if clause[0] == falseLiteral {
+				clause[0], clause[1] = clause[1], clause[0]
+			}
+			if s.value(clause[0]) == 1 {
+				kept = append(kept, clauseIndex)
+				continue
+			}
+			moved := false
+			for k := 2; k < len(clause); k++ {
+				if s.value(clause[k]) != -1 {
+					clause[1], clause[k] = clause[k], clause[1]
+					s.watches[clause[1].index()] = append(s.watches[clause[1].index()], clauseIndex)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			kept = append(kept, clauseIndex)
+			if s.value(clause[0]) == -1 {
+				kept = append(kept, watchList[i+1:]...)
+				s.watches[falseLiteral.index()] = kept
+				return clauseIndex
+			}
+			s.enqueue(clause[0], clauseIndex)
+		}
+		s.watches[falseLiteral.index()] = kept
+	}
+	return -1
+}
+
+//derives the clause of the first unique implication point and the level to jump back to
+func (s *satSolver) analyze(conflict int) ([]literal, int) {
+	learned := []literal{0}
+	seen := make([]bool, s.variables+1)
+	currentLevel := len(s.trailLimits)
+	open := 0
+	implied := literal(0)
+	position := len(s.trail) - 1
+	clause := s.clauses[conflict]
+	for {
+		for _, l := range clause {
+			if l == implied {
+				continue
+			}
+			variable := l.variable()
+			if seen[variable] || s.level[variable] == 0 {
+				continue
+			}
+			seen[variable] = true
+			s.bump(variable)
+			if s.level[variable] == currentLevel {
+				open += 1
+			} else {
+				learned = append(learned, l)
+			}
+		}
+		for !seen[s.trail[position].variable()] {
+			position -= 1
+		}
+		implied = s.trail[position]
+		position -= 1
+		open -= 1
+		if open == 0 {
+			break
+		}
+		clause = s.clauses[s.reason[implied.variable()]]
+	}
+	learned[0] = -implied
+	backtrackLevel := 0
+	for i := 1; i < len(learned); i++ {
+		if s.level[learned[i].variable()] > backtrackLevel {
+			backtrackLevel = s.level[learned[i].variable()]
+			learned[1], learned[i] = learned[i], learned[1]
+		}
+	}
+	s.increment /= 0.95
+	return learned, backtrackLevel
+}
+
+func (s *satSolver) bump(variable int) {
+	s.activity[variable] += s.increment
+	if s.activity[variable] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.increment *= 1e-100
+	}
+}
+
+func (s *satSolver) backtrack(level int) {
+	if len(s.trailLimits) <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLimits[level]; i-- {
+		variable := s.trail[i].variable()
+		s.phase[variable] = s.assignment[variable]
+		s.assignment[variable] = 0
+		s.reason[variable] = -1
+	}
+	s.trail = s.trail[:s.trailLimits[level]]
+	s.trailLimits = s.trailLimits[:level]
+	s.propagated = len(s.trail)
+}
+
+//the i-th element of the luby sequence 1,1,2,1,1,2,4,1,...
+func luby(i int) int {
+	size, power := 1, 1
+	for size < i+1 {
+		size = 2*size + 1
+		power *= 2
+	}
+	for size-1 != i {
+		size = (size - 1) / 2
+		power /= 2
+		i %= size
+	}
+	return power
+}
+
+//returns whether the clauses are satisfiable. The model can then be read with modelValue
+func (s *satSolver) solve() bool {
+	if s.unsatisfiable {
+		return false
+	}
+	conflicts := 0
+	restarts := 0
+	for {
+		conflict := s.propagate()
+		if conflict >= 0 {
+			if len(s.trailLimits) == 0 {
+				s.unsatisfiable = true
+				return false
+			}
+			learned, backtrackLevel := s.analyze(conflict)
+			s.backtrack(backtrackLevel)
+			if len(learned) == 1 {
+				s.enqueue(learned[0], -1)
+			} else {
+				s.enqueue(learned[0], s.attach(learned))
+			}
+			conflicts += 1
+			if conflicts >= 100*luby(restarts) {
+				conflicts = 0
+				restarts += 1
+				s.backtrack(0)
+			}
+			continue
+		}
+		decision := 0
+		for variable := 1; variable <= s.variables; variable++ {
+			if s.assignment[variable] == 0 && (decision == 0 || s.activity[variable] > s.activity[decision]) {
+				decision = variable
+			}
+		}
+		if decision == 0 {
+			s.model = make([]bool, s.variables+1)
+			for variable := 1; variable <= s.variables; variable++ {
+				s.model[variable] = s.assignment[variable] == 1
+			}
+			s.backtrack(0)
+			return true
+		}
+		s.trailLimits = append(s.trailLimits, len(s.trail))
+		s.enqueue(literal(decision)*literal(s.phase[decision]), -1)
+	}
+}
+
+//the added clauses in DIMACS CNF format
+func (s *satSolver) dimacs() string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "p cnf %v %v\n", s.variables, len(s.problem))
+	for _, clause := range s.problem {
+		for _, l := range clause {
+			fmt.Fprintf(&result, "%v ", l)
+		}
+		result.WriteString("0\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSATSolver(t *testing.T) {
+	t.Run("Pigeonhole", func(t *testing.T) {
+		//4 pigeons don't fit into 3 holes
+		solver := newSATSolver()
+		inHole := [4][3]literal{}
+		for p := range inHole {
+			for h := range inHole[p] {
+				inHole[p][h] = solver.newVariable()
+			}
+			solver.addClause(inHole[p][:]...)
+		}
+		for h := 0; h < 3; h++ {
+			for p := 0; p < 4; p++ {
+				for q := p + 1; q < 4; q++ {
+					solver.addClause(-inHole[p][h], -inHole[q][h])
+				}
+			}
+		}
+		if solver.solve() {
+			t.Fail()
+		}
+	})
+	t.Run("EnumerateModels", func(t *testing.T) {
+		//exactly one of three variables, blocking each model in turn
+		solver := newSATSolver()
+		variables := []literal{solver.newVariable(), solver.newVariable(), solver.newVariable()}
+		solver.addClause(variables...)
+		for i := range variables {
+			for j := i + 1; j < len(variables); j++ {
+				solver.addClause(-variables[i], -variables[j])
+			}
+		}
+		models := 0
+		for solver.solve() {
+			models += 1
+			blocking := []literal{}
+			for _, variable := range variables {
+				if solver.modelValue(variable) {
+					blocking = append(blocking, -variable)
+				} else {
+					blocking = append(blocking, variable)
+				}
+			}
+			solver.addClause(blocking...)
+		}
+		if models != 3 {
+			t.Fail()
+		}
+	})
+	t.Run("DIMACS", func(t *testing.T) {
+		solver := newSATSolver()
+		a := solver.newVariable()
+		b := solver.newVariable()
+		solver.addClause(a, -b)
+		solver.addClause(b)
+		if solver.dimacs() != "p cnf 2 2\n1 -2 0\n2 0\n" {
+			t.Fail()
+		}
+	})
+}
+
+func TestSATdecider(t *testing.T) {
+	tm, _ := parseTM("1RB0RA_1LA---")
+	solver := newSATSolver()
+	encoding := newDFAPairEncoding(solver, tm, 2, 2, true)
+	if !solver.solve() {
+		t.FailNow()
+	}
+	leftWFA, rightWFA := encoding.decode(solver)
+	if closed, _, _, _ := findClosure(tm, leftWFA, rightWFA); !closed {
+		t.Fail()
+	}
+	if !SATdecider(tm, 3, 0, 0, 0, searchOptions{}, -1) {
+		t.Fail()
+	}
+	if !strings.HasPrefix(SATdimacs(tm, 3), "c 1RB0RA_1LA---\n") {
+		t.Fail()
+	}
+}
+
+func TestClosurePair(t *testing.T) {
+	tm, _ := parseTM("1RB0RA_1LA---")
+	//the closure and the transitions it reads
+	readTransitions := func(leftWFA, rightWFA dwfa) string {
+		closure := []config{{TMSTARTSTATE, TMSTARTSYMBOL, 0, 0}}
+		seen := set[config]{closure[0]: {}}
+		used := set[string]{}
+		for i := 0; i < len(closure); i++ {
+			for _, next := range nextConfigsWithWeightChange(closure[i], tm, leftWFA, rightWFA) {
+				used.add(fmt.Sprint(closure[i], next.config))
+				if !seen.contains(next.config) {
+					seen.add(next.config)
+					closure = append(closure, next.config)
+				}
+			}
+		}
+		keys := []string{}
+		for key := range used {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Sprint(keys)
+	}
+	models := func(block func(*satSolver, dfaPairEncoding) []literal) []string {
+		solver := newSATSolver()
+		encoding := newDFAPairEncoding(solver, tm, 3, 2, false)
+		result := []string{}
+		for solver.solve() {
+			result = append(result, readTransitions(encoding.decode(solver)))
+			solver.addClause(block(solver, encoding)...)
+		}
+		return result
+	}
+	//blocking whole models tries pairs that only differ in transitions the closure never reads
+	all := models(func(solver *satSolver, encoding dfaPairEncoding) []literal {
+		result := []literal{}
+		for _, transitions := range [][][][]literal{encoding.left, encoding.right} {
+			for s := range transitions {
+				for a := range transitions[s] {
+					for t := range transitions[s][a] {
+						if solver.modelValue(transitions[s][a][t]) {
+							result = append(result, -transitions[s][a][t])
+						} else {
+							result = append(result, transitions[s][a][t])
+						}
+					}
+				}
+			}
+		}
+		return result
+	})
+	pairs := set[string]{}
+	used := models(func(solver *satSolver, encoding dfaPairEncoding) []literal {
+		leftWFA, rightWFA, blockingClause := encoding.closurePair(solver, tm)
+		//the restricted pair has the same closure as the model and nothing else
+		if readTransitions(leftWFA, rightWFA) != readTransitions(encoding.decode(solver)) {
+			t.Error(leftWFA, rightWFA)
+		}
+		pairs.add(fmt.Sprint(leftWFA, rightWFA))
+		return blockingClause
+	})
+	//one model for each way the closure reads the transitions
+	distinct := set[string]{}
+	for _, model := range all {
+		distinct.add(model)
+	}
+	if len(used) != len(distinct) || len(used) >= len(all) || len(pairs) != len(used) {
+		t.Error(len(used), len(distinct), len(all), len(pairs))
+	}
+}
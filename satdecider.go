@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+//the SAT encoding of a pair of base dfa with the given number of live states that is closed under the TM transitions.
+//besides the live states each dfa has the dead state 1, which is never used in the encoding:
+//a missing transition goes to the dead state and the closure must never need one.
+type dfaPairEncoding struct {
+	leftStates, rightStates int
+	//whether the transition from state on symbol goes to the target state
+	left, right [][][]literal
+	//whether the config is part of the closure, indexed by tm state, tm symbol, left state, right state
+	configs [][][][]literal
+}
+
+//each live state of the encoding is a dwfa state, skipping the dead state 1
+func liveStateNumber(state int) wfaState {
+	if state == 0 {
+		return 0
+	}
+	return wfaState(state + 1)
+}
+
+//every left transition that writes symbol and moves right needs a defined left transition, while every predecessor
+//of the right state can be the next right state and vice versa. The start state of each dfa loops on the blank symbol.
+//if noHaltingConfigs is set, the closure has to avoid the halting configs on its own.
+//to break the symmetry between renumbered dfa each state other than the start state needs a transition from a smaller state,
+//which also means that all states are used
+func newDFAPairEncoding(solver *satSolver, tm turingMachine, leftStates, rightStates int, noHaltingConfigs bool) dfaPairEncoding {
+	encoding := dfaPairEncoding{leftStates: leftStates, rightStates: rightStates}
+	encoding.left = newTransitionVariables(solver, leftStates, tm.symbols)
+	encoding.right = newTransitionVariables(solver, rightStates, tm.symbols)
+	encoding.configs = make([][][][]literal, tm.states)
+	for q := range encoding.configs {
+		encoding.configs[q] = make([][][]literal, tm.symbols)
+		for a := range encoding.configs[q] {
+			encoding.configs[q][a] = make([][]literal, leftStates)
+			for l := range encoding.configs[q][a] {
+				encoding.configs[q][a][l] = make([]literal, rightStates)
+				for r := range encoding.configs[q][a][l] {
+					encoding.configs[q][a][l][r] = solver.newVariable()
+				}
+			}
+		}
+	}
+	addTransitionClauses(solver, encoding.left)
+	addTransitionClauses(solver, encoding.right)
+	solver.addClause(encoding.configs[TMSTARTSTATE][TMSTARTSYMBOL][0][0])
+	for q := range encoding.configs {
+		for a := range encoding.configs[q] {
+			if haltsNextStep(tm, tmState(q), symbol(a)) {
+				if noHaltingConfigs {
+					for l := range encoding.configs[q][a] {
+						for r := range encoding.configs[q][a][l] {
+							solver.addClause(-encoding.configs[q][a][l][r])
+						}
+					}
+				}
+				continue
+			}
+			tmTransition := tm.transitions[tmState(q)][symbol(a)]
+			//the side the head moves away from reads the written symbol, the other side gives up a symbol
+			writing, reading := encoding.left, encoding.right
+			if tmTransition.direction == L {
+				writing, reading = encoding.right, encoding.left
+			}
+			for l := range encoding.configs[q][a] {
+				for r := range encoding.configs[q][a][l] {
+					current := encoding.configs[q][a][l][r]
+					writingState, readingState := l, r
+					if tmTransition.direction == L {
+						writingState, readingState = r, l
+					}
+					defined := []literal{-current}
+					for nextWritingState := range writing {
+						writingTransition := writing[writingState][tmTransition.symbol][nextWritingState]
+						defined = append(defined, writingTransition)
+						for nextReadingState := range reading {
+							for nextSymbol := range reading[nextReadingState] {
+								readingTransition := reading[nextReadingState][nextSymbol][readingState]
+								nextLeftState, nextRightState := nextWritingState, nextReadingState
+								if tmTransition.direction == L {
+									nextLeftState, nextRightState = nextReadingState, nextWritingState
+								}
+								next := encoding.configs[tmTransition.tmState][nextSymbol][nextLeftState][nextRightState]
+								solver.addClause(-current, -writingTransition, -readingTransition, next)
+							}
+						}
+					}
+					solver.addClause(defined...)
+				}
+			}
+		}
+	}
+	return encoding
+}
+
+func newTransitionVariables(solver *satSolver, states, symbols int) [][][]literal {
+	result := make([][][]literal, states)
+	for s := range result {
+		result[s] = make([][]literal, symbols)
+		for a := range result[s] {
+			result[s][a] = make([]literal, states)
+			for t := range result[s][a] {
+				result[s][a][t] = solver.newVariable()
+			}
+		}
+	}
+	return result
+}
+
+//the dfa are deterministic, the start state loops on the blank symbol and every other state has a transition from a smaller state
+func addTransitionClauses(solver *satSolver, transitions [][][]literal) {
+	for s := range transitions {
+		for a := range transitions[s] {
+			for t := range transitions[s][a] {
+				for u := t + 1; u < len(transitions[s][a]); u++ {
+					solver.addClause(-transitions[s][a][t], -transitions[s][a][u])
+				}
+			}
+		}
+	}
+	solver.addClause(transitions[0][TMSTARTSYMBOL][0])
+	for t := 1; t < len(transitions); t++ {
+		predecessors := []literal{}
+		for s := 0; s < t; s++ {
+			for a := range transitions[s] {
+				predecessors = append(predecessors, transitions[s][a][t])
+			}
+		}
+		solver.addClause(predecessors...)
+	}
+}
+
+//the dwfa pair of the last model with all weights 0
+func (encoding dfaPairEncoding) decode(solver *satSolver) (dwfa, dwfa) {
+	return decodeDFA(solver, encoding.left), decodeDFA(solver, encoding.right)
+}
+
+func decodeDFA(solver *satSolver, transitions [][][]literal) dwfa {
+	result := dwfa{
+		states:      len(transitions) + 1,
+		symbols:     len(transitions[0]),
+		startState:  0,
+		transitions: map[wfaState]map[symbol]wfaTransition{},
+	}
+	for i := 0; i < result.states; i++ {
+		result.transitions[wfaState(i)] = map[symbol]wfaTransition{}
+		for j := 0; j < result.symbols; j++ {
+			result.transitions[wfaState(i)][symbol(j)] = wfaTransition{1, 0}
+		}
+	}
+	for s := range transitions {
+		for a := range transitions[s] {
+			for t := range transitions[s][a] {
+				if solver.modelValue(transitions[s][a][t]) {
+					result.transitions[liveStateNumber(s)][symbol(a)] = wfaTransition{liveStateNumber(t), 0}
+				}
+			}
+		}
+	}
+	return result
+}
+
+//the variable index of a live dwfa state, the inverse of liveStateNumber
+func encodingState(state wfaState) int {
+	if state == 0 {
+		return 0
+	}
+	return int(state) - 1
+}
+
+//the dwfa pair of the last model restricted to the transitions its closure reads, like the enumeration builds them, and the clause
+//that forbids those transitions, so the next model has to have a different closure. The closure reads the transition of the
+//side the head moves away from on the written symbol and all transitions into the state of the other side, for every config.
+//models that only differ in other transitions have the same restricted pair, so none of them is weighted again
+func (encoding dfaPairEncoding) closurePair(solver *satSolver, tm turingMachine) (dwfa, dwfa, []literal) {
+	leftWFA, rightWFA := encoding.decode(solver)
+	readLeftWFA, readRightWFA := withoutTransitions(leftWFA), withoutTransitions(rightWFA)
+	used := set[literal]{}
+	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}
+	closure := set[config]{initialConfig: {}}
+	todo := []config{initialConfig}
+	for len(todo) > 0 {
+		currentConfig := todo[0]
+		todo = todo[1:]
+		if haltsNextStep(tm, currentConfig.tmState, currentConfig.tmSymbol) {
+			continue
+		}
+		tmTransition := tm.transitions[currentConfig.tmState][currentConfig.tmSymbol]
+		writing, reading := encoding.left, encoding.right
+		writingWFA, readingWFA := leftWFA, rightWFA
+		readWritingWFA, readReadingWFA := readLeftWFA, readRightWFA
+		writingState, readingState := currentConfig.leftState, currentConfig.rightState
+		if tmTransition.direction == L {
+			writing, reading = encoding.right, encoding.left
+			writingWFA, readingWFA = rightWFA, leftWFA
+			readWritingWFA, readReadingWFA = readRightWFA, readLeftWFA
+			writingState, readingState = readingState, writingState
+		}
+		for _, transition := range writing[encodingState(writingState)][tmTransition.symbol] {
+			used.add(transition)
+		}
+		readWritingWFA.transitions[writingState][tmTransition.symbol] = writingWFA.transitions[writingState][tmTransition.symbol]
+		for s := range reading {
+			for a := range reading[s] {
+				used.add(reading[s][a][encodingState(readingState)])
+				if readingWFA.transitions[liveStateNumber(s)][symbol(a)].wfaState == readingState {
+					readReadingWFA.transitions[liveStateNumber(s)][symbol(a)] = readingWFA.transitions[liveStateNumber(s)][symbol(a)]
+				}
+			}
+		}
+		for _, next := range nextConfigsWithWeightChange(currentConfig, tm, leftWFA, rightWFA) {
+			if !closure.contains(next.config) {
+				closure.add(next.config)
+				todo = append(todo, next.config)
+			}
+		}
+	}
+	result := []literal{}
+	for transition := range used {
+		if solver.modelValue(transition) {
+			result = append(result, -transition)
+		} else {
+			result = append(result, transition)
+		}
+	}
+	//the same clause every time, so the search doesn't depend on the map order
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return readLeftWFA, readRightWFA, result
+}
+
+//a copy of the dwfa where every transition goes to the dead state, except for the loop of the start state on the blank symbol
+func withoutTransitions(wfa dwfa) dwfa {
+	result := copyWFA(wfa)
+	for state, transitions := range result.transitions {
+		for a := range transitions {
+			transitions[a] = wfaTransition{1, 0}
+		}
+		if state == wfa.startState {
+			transitions[TMSTARTSYMBOL] = wfa.transitions[state][TMSTARTSYMBOL]
+		}
+	}
+	return result
+}
+
+//finds closed base dfa pairs with a SAT solver instead of enumerating them, and weights each of them like the enumeration does.
+//pairs with fewer states are tried first. maxStates includes the dead state, just like the state limits of MITMWFARdecider
+func SATdecider(tm turingMachine, maxStates, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
+	noHaltingConfigs := maxWeightPairs == 0 && !options.solveWeights
+	for liveStates := 2; liveStates <= 2*(maxStates-1); liveStates++ {
+		for leftStates := 1; leftStates < liveStates && leftStates < maxStates; leftStates++ {
+			rightStates := liveStates - leftStates
+			if rightStates >= maxStates {
+				continue
+			}
+			solver := newSATSolver()
+			encoding := newDFAPairEncoding(solver, tm, leftStates, rightStates, noHaltingConfigs)
+			for solver.solve() {
+				leftWFA, rightWFA, blockingClause := encoding.closurePair(solver, tm)
+				options.statistics.closedPair()
+				if findWeights(tm, leftWFA, rightWFA, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
+					return true
+				}
+				solver.addClause(blockingClause...)
+			}
+		}
+	}
+	return false
+}
+
+//the SAT problem for the largest dfa pair in DIMACS format, with comments that name the variables
+func SATdimacs(tm turingMachine, maxStates int) string {
+	solver := newSATSolver()
+	encoding := newDFAPairEncoding(solver, tm, maxStates-1, maxStates-1, false)
+	result := fmt.Sprintf("c %v\n", tm)
+	for _, side := range []struct {
+		name        string
+		transitions [][][]literal
+	}{{"left", encoding.left}, {"right", encoding.right}} {
+		for s := range side.transitions {
+			for a := range side.transitions[s] {
+				for t := range side.transitions[s][a] {
+					result += fmt.Sprintf("c %v %v %v %v %v\n", side.name, liveStateNumber(s), a, liveStateNumber(t), side.transitions[s][a][t])
+				}
+			}
+		}
+	}
+	for q := range encoding.configs {
+		for a := range encoding.configs[q] {
+			for l := range encoding.configs[q][a] {
+				for r := range encoding.configs[q][a][l] {
+					result += fmt.Sprintf("c config %v %v %v %v %v\n", tmState(q), a, liveStateNumber(l), liveStateNumber(r), encoding.configs[q][a][l][r])
+				}
+			}
+		}
+	}
+	return result + solver.dimacs()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//a TM running on a tape that is blank at the start. The tape is big enough for the head to never leave it
+type simulation struct {
+	tm     turingMachine
+	tape   []symbol
+	head   int
+	state  tmState
+	steps  int
+	halted bool
+	//the leftmost and rightmost cell the head has visited
+	leftmost, rightmost int
+}
+
+func newSimulation(tm turingMachine, maxSteps int) *simulation {
+	return &simulation{
+		tm:        tm,
+		tape:      make([]symbol, 2*maxSteps+1),
+		head:      maxSteps,
+		state:     TMSTARTSTATE,
+		leftmost:  maxSteps,
+		rightmost: maxSteps,
+	}
+}
+
+func (s *simulation) step() {
+	if haltsNextStep(s.tm, s.state, s.tape[s.head]) {
+		s.halted = true
+		s.steps += 1
+		return
+	}
+	transition := s.tm.transitions[s.state][s.tape[s.head]]
+	s.tape[s.head] = transition.symbol
+	s.state = transition.tmState
+	if transition.direction == L {
+		s.head -= 1
+	} else {
+		s.head += 1
+	}
+	if s.head < s.leftmost {
+		s.leftmost = s.head
+	}
+	if s.head > s.rightmost {
+		s.rightmost = s.head
+	}
+	s.steps += 1
+}
+
+//the visited part of the tape
+func (s *simulation) visitedTape() []symbol {
+	return append([]symbol{}, s.tape[s.leftmost:s.rightmost+1]...)
+}
+
+//a config of the simulation, with the tape from the leftmost visited cell
+type snapshot struct {
+	steps    int
+	state    tmState
+	head     int
+	leftmost int
+	tape     []symbol
+}
+
+func (s *simulation) snapshot() snapshot {
+	return snapshot{s.steps, s.state, s.head, s.leftmost, s.visitedTape()}
+}
+
+//the symbol of the snapshot at the absolute tape position
+func (s snapshot) symbolAt(position int) symbol {
+	if position < s.leftmost || position >= s.leftmost+len(s.tape) {
+		return TMSTARTSYMBOL
+	}
+	return s.tape[position-s.leftmost]
+}
+
+//whether the simulation is in the config of the snapshot. Cells the snapshot doesn't have are blank
+func (s *simulation) matches(saved snapshot) bool {
+	if s.state != saved.state || s.head != saved.head {
+		return false
+	}
+	for position := s.leftmost; position <= s.rightmost; position++ {
+		if s.tape[position] != saved.symbolAt(position) {
+			return false
+		}
+	}
+	return true
+}
+
+//runs the TM for up to maxSteps steps from the blank tape and decides it if it halts, repeats a config (cycler)
+//or repeats a config shifted along the tape at a new record position (translated cycler).
+//the label names the result, followed by the step the repetition starts at and its length.
+//a translated cycler at step t with length p and direction d was at the same state at step t-p, the head was at a
+//new record to the d side both times and the cells between the head and the furthest cell visited
+//on the other side since step t-p are the same at both times, just shifted.
+func simulationPrefilter(tm turingMachine, maxSteps int) (string, bool) {
+	s := newSimulation(tm, maxSteps)
+	//Brent's cycle detection: compare to a config that is saved again after each power of two steps
+	saved := s.snapshot()
+	power := 1
+	//the head position after each step
+	heads := []int{s.head}
+	//configs with the head at a new record position, for each side
+	records := map[direction][]snapshot{L: {s.snapshot()}, R: {s.snapshot()}}
+	for s.steps < maxSteps {
+		oldLeftmost, oldRightmost := s.leftmost, s.rightmost
+		s.step()
+		if s.halted {
+			return fmt.Sprintf("halt %v", s.steps), true
+		}
+		heads = append(heads, s.head)
+		//the saved snapshot can't have visited cells the simulation hasn't
+		if s.matches(saved) {
+			return fmt.Sprintf("cycler %v %v", saved.steps, s.steps-saved.steps), true
+		}
+		if s.steps-saved.steps == power {
+			saved = s.snapshot()
+			power *= 2
+		}
+		side := L
+		switch {
+		case s.leftmost < oldLeftmost:
+		case s.rightmost > oldRightmost:
+			side = R
+		default:
+			continue
+		}
+		current := s.snapshot()
+		for _, record := range records[side] {
+			if record.state == current.state && translatedRepetition(record, current, heads, side) {
+				return fmt.Sprintf("translated-cycler %v %v %v", record.steps, current.steps-record.steps, side), true
+			}
+		}
+		records[side] = append(records[side], current)
+	}
+	return "", false
+}
+
+//whether both records have the same tape between the head and the furthest cell visited in between on the other side
+func translatedRepetition(earlier, later snapshot, heads []int, side direction) bool {
+	shift := later.head - earlier.head
+	furthest := earlier.head
+	for _, head := range heads[earlier.steps : later.steps+1] {
+		if side == R && head < furthest || side == L && head > furthest {
+			furthest = head
+		}
+	}
+	from, to := furthest, earlier.head
+	if side == L {
+		from, to = earlier.head, furthest
+	}
+	for position := from; position <= to; position++ {
+		if earlier.symbolAt(position) != later.symbolAt(position+shift) {
+			return false
+		}
+	}
+	return true
+}
+
+//simulates each TM from the input and writes the decided ones with their label to the output.
+//returns a scanner over the remaining TMs
+func prefilterTMs(input *bufio.Scanner, maxSteps int, output io.Writer) *bufio.Scanner {
+	remaining := strings.Builder{}
+	for input.Scan() {
+		tm, err := parseTM(input.Text())
+		if err != nil {
+			remaining.WriteString(input.Text() + "\n")
+			continue
+		}
+		if label, decided := simulationPrefilter(tm, maxSteps); decided {
+			fmt.Fprintln(output, tm, label)
+			continue
+		}
+		remaining.WriteString(input.Text() + "\n")
+	}
+	return bufio.NewScanner(strings.NewReader(remaining.String()))
+}
+
+//the config of the simulation as the wfa see it, with the weight sum of both halves of the tape
+func (s *simulation) wfaConfig(leftWFA, rightWFA vectorWFA) (config, weightVector) {
+	tape := tapeConfiguration{
+		state:     s.state,
+		leftHalf:  s.tape[s.leftmost:s.head],
+		head:      s.tape[s.head],
+		rightHalf: s.tape[s.head+1 : s.rightmost+1],
+	}
+	return tape.wfaConfig(leftWFA, rightWFA)
+}
+
+//simulates the TM for up to maxSteps steps from the blank tape and checks that every config it reaches is accepted.
+//a verified certificate can't be escaped, so any escape is reported as a soundness bug on stderr
+func simulationCheck(tm turingMachine, leftWFA, rightWFA vectorWFA, accepts func(config, weightVector) bool, maxSteps int) bool {
+	if maxSteps <= 0 {
+		return true
+	}
+	s := newSimulation(tm, maxSteps)
+	for {
+		config, weights := s.wfaConfig(leftWFA, rightWFA)
+		if !accepts(config, weights) {
+			fmt.Fprintln(os.Stderr, errorString(fmt.Sprintf("soundness bug: TM \"%v\" escapes the accept set at step %v in config %v with weights %v", tm, s.steps, config, weights)))
+			return false
+		}
+		if s.steps >= maxSteps {
+			return true
+		}
+		s.step()
+		if s.halted {
+			fmt.Fprintln(os.Stderr, errorString(fmt.Sprintf("soundness bug: TM \"%v\" halts at step %v from an accepted config", tm, s.steps)))
+			return false
+		}
+	}
+}
+
+func scalarSimulationCheck(tm turingMachine, leftWFA, rightWFA dwfa, acceptSet acceptSet, maxSteps int) bool {
+	accepts := func(c config, weights weightVector) bool {
+		bounds, ok := acceptSet[c]
+		return ok && boundsContain(bounds, weights[0])
+	}
+	return simulationCheck(tm, vectorWFA{leftWFA}, vectorWFA{rightWFA}, accepts, maxSteps)
+}
+
+func vectorSimulationCheck(tm turingMachine, leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet, maxSteps int) bool {
+	accepts := func(c config, weights weightVector) bool {
+		polyhedron, ok := acceptSet[c]
+		return ok && polyhedronContains(polyhedron, weights)
+	}
+	return simulationCheck(tm, leftWFA, rightWFA, accepts, maxSteps)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestSimulationPrefilter(t *testing.T) {
+	for tmString, expectedLabel := range map[string]string{
+		"1RB1LB_1LA1RZ": "halt 6",
+		"0RB---_0LA---": "cycler 1 2",
+		"1RB0RA_1LA---": "translated-cycler 0 4 R",
+		"1LB0LA_1RA---": "translated-cycler 0 4 L",
+	} {
+		tm, _ := parseTM(tmString)
+		label, decided := simulationPrefilter(tm, 100)
+		if !decided || label != expectedLabel {
+			t.Error(tmString, label)
+		}
+	}
+	//a solved holdout isn't decided by simulation
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	if _, decided := simulationPrefilter(tm, 1000); decided {
+		t.Fail()
+	}
+}
+
+func TestPrefilterTMs(t *testing.T) {
+	input := bufio.NewScanner(strings.NewReader("1RB1LB_1LA1RZ\n1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE\n"))
+	output := strings.Builder{}
+	remaining := prefilterTMs(input, 1000, &output)
+	if output.String() != "1RB1LB_1LA--- halt 6\n" {
+		t.Error(output.String())
+	}
+	if !remaining.Scan() || remaining.Text() != "1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE" || remaining.Scan() {
+		t.Fail()
+	}
+}
+
+func TestSimulationCheck(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseWFA("0,0;1,0_0,-1;1,0")
+	acceptSet := findAcceptSet(tm, leftWFA, rightWFA, deriveSpecialSets(leftWFA), deriveSpecialSets(rightWFA), searchOptions{})
+	if !scalarSimulationCheck(tm, leftWFA, rightWFA, acceptSet, 10000) {
+		t.Fail()
+	}
+	//the TM reaches configs with a weight sum of at least 1, which this accept set no longer contains
+	for config, bounds := range acceptSet {
+		bounds[UPPER] = 0
+		acceptSet[config] = bounds
+	}
+	if scalarSimulationCheck(tm, leftWFA, rightWFA, acceptSet, 10000) {
+		t.Fail()
+	}
+
+	vectorTM := exampleVectorTM()
+	leftVectorWFA, rightVectorWFA := exampleVectorWFAs()
+	vectorAcceptSet := findVectorAcceptSet(vectorTM, leftVectorWFA, rightVectorWFA, deriveVectorSpecialSets(leftVectorWFA), deriveVectorSpecialSets(rightVectorWFA), nil)
+	if !vectorSimulationCheck(vectorTM, leftVectorWFA, rightVectorWFA, vectorAcceptSet, 10000) {
+		t.Fail()
+	}
+	delete(vectorAcceptSet, config{TMSTARTSTATE, TMSTARTSYMBOL, leftVectorWFA[0].startState, rightVectorWFA[0].startState})
+	if vectorSimulationCheck(vectorTM, leftVectorWFA, rightVectorWFA, vectorAcceptSet, 10000) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//sizes in pixels: the cells, the gap before the annotations and the width of a character in them
+const SPACETIMECELLWIDTH = 8
+const SPACETIMEROWHEIGHT = 10
+const SPACETIMEFONTSIZE = 9
+const SPACETIMEGAP = 10
+const SPACETIMECHARWIDTH = 6
+
+//colors of the tape symbols, the blank symbol is white
+var spaceTimeSymbolColors = []string{"#ffffff", "#404040", "#e07030", "#3070e0", "#30a050", "#a040c0"}
+
+//a row of the space-time diagram: the config of the TM and what the wfa make of it
+type spaceTimeRow struct {
+	snapshot
+	config   config
+	weights  weightVector
+	accepted bool
+}
+
+//simulates the TM for up to steps steps and draws a row for each config, starting with the blank tape.
+//the head cell is outlined in a color for its state. Each row is annotated with the states the wfa are in,
+//the weight sum and whether the accept set contains the config
+func spaceTimeSVG(tm turingMachine, leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet, steps int) string {
+	s := newSimulation(tm, steps)
+	rows := []spaceTimeRow{}
+	for {
+		config, weights := s.wfaConfig(leftWFA, rightWFA)
+		polyhedron, ok := acceptSet[config]
+		rows = append(rows, spaceTimeRow{s.snapshot(), config, weights, ok && polyhedronContains(polyhedron, weights)})
+		if s.steps >= steps {
+			break
+		}
+		s.step()
+		if s.halted {
+			break
+		}
+	}
+	columns := s.rightmost - s.leftmost + 1
+	annotationX := columns*SPACETIMECELLWIDTH + SPACETIMEGAP
+	annotations := []string{}
+	longest := 0
+	for _, row := range rows {
+		annotation := fmt.Sprintf("%v %v l%v r%v w%v", row.steps, row.state, row.config.leftState, row.config.rightState, weightVectorString(row.weights))
+		if !row.accepted {
+			annotation += " escaped"
+		}
+		annotations = append(annotations, annotation)
+		if len(annotation) > longest {
+			longest = len(annotation)
+		}
+	}
+	width := annotationX + longest*SPACETIMECHARWIDTH
+	height := len(rows) * SPACETIMEROWHEIGHT
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" font-family=\"monospace\" font-size=\"%v\">\n", width, height, SPACETIMEFONTSIZE)
+	fmt.Fprintf(&result, "<title>%v</title>\n", tm)
+	fmt.Fprintf(&result, "<rect width=\"%v\" height=\"%v\" fill=\"white\"/>\n", width, height)
+	for i, row := range rows {
+		y := i * SPACETIMEROWHEIGHT
+		for position := s.leftmost; position <= s.rightmost; position++ {
+			symbol := row.symbolAt(position)
+			if symbol == TMSTARTSYMBOL {
+				continue
+			}
+			x := (position - s.leftmost) * SPACETIMECELLWIDTH
+			fmt.Fprintf(&result, "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\" fill=\"%v\"/>\n", x, y, SPACETIMECELLWIDTH, SPACETIMEROWHEIGHT, spaceTimeSymbolColors[int(symbol)%len(spaceTimeSymbolColors)])
+		}
+		x := (row.head - s.leftmost) * SPACETIMECELLWIDTH
+		fmt.Fprintf(&result, "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\" fill=\"none\" stroke=\"hsl(%v,80%%,45%%)\" stroke-width=\"2\"/>\n", x+1, y+1, SPACETIMECELLWIDTH-2, SPACETIMEROWHEIGHT-2, 360*int(row.state)/tm.states)
+		fill := "black"
+		if !row.accepted {
+			fill = "red"
+		}
+		fmt.Fprintf(&result, "<text x=\"%v\" y=\"%v\" fill=\"%v\">%v</text>\n", annotationX, y+SPACETIMEROWHEIGHT-2, fill, annotations[i])
+	}
+	result.WriteString("</svg>\n")
+	return result.String()
+}
+
+//writes the space-time diagram of each full certificate from the input to a file named after the TM
+func runSpaceTime(input *bufio.Scanner, steps int) {
+	for input.Scan() {
+		tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, err := scanFullCertificate(input)
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		if !verifyFullCertificate(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) {
+			fmt.Fprintln(os.Stderr, errorString("Certificate doesn't verify: \""+tm.String()+"\""))
+			continue
+		}
+		fileName := tm.String() + ".svg"
+		if err := os.WriteFile(fileName, []byte(spaceTimeSVG(tm, leftWFA, rightWFA, acceptSet, steps)), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		fmt.Println(fileName)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSpaceTimeSVG(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseVectorWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseVectorWFA("0,0;1,0_0,-1;1,0")
+	acceptSet, _ := parseVectorAcceptSet(findAcceptSet(tm, leftWFA[0], rightWFA[0], deriveSpecialSets(leftWFA[0]), deriveSpecialSets(rightWFA[0]), searchOptions{}).String())
+	result := spaceTimeSVG(tm, leftWFA, rightWFA, acceptSet, 50)
+	decoder := xml.NewDecoder(strings.NewReader(result))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	//a row for the blank tape and one for each step, annotated with the wfa states and the weight sum
+	if strings.Count(result, "<text") != 51 || strings.Contains(result, "escaped") {
+		t.Fail()
+	}
+	for _, annotation := range []string{">0 A l0 r0 w0<", ">2 C l3 r0 w1<"} {
+		if !strings.Contains(result, annotation) {
+			t.Error(annotation)
+		}
+	}
+	delete(acceptSet, config{C, 0, 3, 0})
+	if !strings.Contains(spaceTimeSVG(tm, leftWFA, rightWFA, acceptSet, 50), ">2 C l3 r0 w1 escaped<") {
+		t.Fail()
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+//a change of the accept set while it is built: the predecessor config and the weight change that lead to the config,
+//with its bounds before and after. Configs that are new have no bounds before
+type derivationStep struct {
+	predecessor config
+	configWithWeight
+	before, after bounds
+	//whether the bound was dropped to the hard bound instead of moving to the new weight sum,
+	//because the interval would have become longer than MAXFINITEINTERVALL
+	widenedLower, widenedUpper bool
+	//whether gaps were closed because there would have been more intervals than allowed
+	widenedGaps bool
+	//whether the congruence got a smaller modulus or was dropped
+	widenedCongruence bool
+}
+
+//all changes of the accept set in the order they happened
+type derivationTrace struct {
+	steps []derivationStep
+	//the config without a TM transition the search ran into, if it failed there
+	halting *config
+}
+
+func copyBounds(oldBounds bounds) bounds {
+	if oldBounds == nil {
+		return nil
+	}
+	result := bounds{}
+	for boundType, value := range oldBounds {
+		result[boundType] = value
+	}
+	return result
+}
+
+func (t *derivationTrace) record(predecessor config, predecessorBounds bounds, next configWithWeight, before bounds, acceptSet acceptSet, leftSpecialSets, rightSpecialSets specialSets) {
+	step := derivationStep{
+		predecessor:      predecessor,
+		configWithWeight: next,
+		before:           before,
+		after:            copyBounds(acceptSet[next.config]),
+	}
+	if before != nil {
+		//the bounds the next config would have needed, as changeAcceptSetToContainNextConfigWithWeightChange computes them
+		hardBounds, _ := sumOfStateBounds(leftSpecialSets, rightSpecialSets, next.config.leftState, next.config.rightState)
+		nextBounds, _ := clipBounds(shiftBounds(predecessorBounds, next.weight), hardBounds)
+		for _, bound := range []boundType{LOWER, UPPER} {
+			oldValue, hadBound := before[bound]
+			newValue, hasBound := step.after[bound]
+			nextValue, nextHasBound := nextBounds[bound]
+			if hadBound && (!hasBound || newValue != oldValue) && (!hasBound || !nextHasBound || newValue != nextValue) {
+				if bound == LOWER {
+					step.widenedLower = true
+				} else {
+					step.widenedUpper = true
+				}
+			}
+		}
+		//the gaps of the exact union of both bounds, which joinBounds only keeps if the intervals allow it
+		step.widenedGaps = fmt.Sprint(step.after.gaps()) != fmt.Sprint(joinGaps(before, nextBounds, len(before.gaps())+len(nextBounds.gaps())+2))
+		if modulus, ok := before[MODULUS]; ok {
+			step.widenedCongruence = step.after[MODULUS] != modulus
+		}
+	}
+	t.steps = append(t.steps, step)
+}
+
+//"B,0,2,0 <- A,0,0,0 +1: new 1,1" or "C,0,3,0 <- B,0,2,0 +1: 1,5 -> 1,- widened upper"
+func (s derivationStep) String() string {
+	result := fmt.Sprintf("%v <- %v %+d: ", s.config, s.predecessor, s.weight)
+	if s.before == nil {
+		return result + fmt.Sprintf("new %v", s.after)
+	}
+	result += fmt.Sprintf("%v -> %v", s.before, s.after)
+	if s.widenedLower {
+		result += " widened lower"
+	}
+	if s.widenedUpper {
+		result += " widened upper"
+	}
+	if s.widenedGaps {
+		result += " widened gaps"
+	}
+	if s.widenedCongruence {
+		result += " widened congruence"
+	}
+	return result
+}
+
+func (t derivationTrace) String() string {
+	result := ""
+	for _, step := range t.steps {
+		result += fmt.Sprintln(step)
+	}
+	return result
+}
+
+//the steps that first introduced each config on the way from the initial config to the given one
+func (t derivationTrace) chain(target config) []derivationStep {
+	introductions := map[config]derivationStep{}
+	for _, step := range t.steps {
+		if step.before == nil {
+			introductions[step.config] = step
+		}
+	}
+	result := []derivationStep{}
+	for {
+		step, ok := introductions[target]
+		if !ok {
+			break
+		}
+		result = append([]derivationStep{step}, result...)
+		target = step.predecessor
+		delete(introductions, step.config)
+	}
+	return result
+}
+
+//builds the accept set for each short certificate from the input and prints how each config got its bounds.
+//if the search runs into a halting config the chain of configs leading there is printed as well
+func runTrace(input *bufio.Scanner, options searchOptions) {
+	for input.Scan() {
+		tm, err := parseTM(input.Text())
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		input.Scan()
+		leftWFA, err := parseWFA(input.Text())
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		input.Scan()
+		rightWFA, err := parseWFA(input.Text())
+		if err != nil {
+			if input.Text() != "" {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		trace := derivationTrace{}
+		initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}
+		traceAcceptSet(tm, leftWFA, rightWFA, deriveSpecialSets(leftWFA), deriveSpecialSets(rightWFA), options, &trace)
+		fmt.Println(tm)
+		fmt.Println(initialConfig, "initial")
+		fmt.Print(trace)
+		if trace.halting != nil {
+			fmt.Println("halting config", *trace.halting, "reached by:")
+			for _, step := range trace.chain(*trace.halting) {
+				fmt.Println(step)
+			}
+		}
+	}
+}
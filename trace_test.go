@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTraceAcceptSet(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1LC_1RD1RC_1LE1LD_0RA0LE")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseWFA("0,0;1,0_0,-1;1,0")
+	leftSpecialSets := deriveSpecialSets(leftWFA)
+	rightSpecialSets := deriveSpecialSets(rightWFA)
+	trace := derivationTrace{}
+	acceptSet := traceAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, searchOptions{}, &trace)
+	if !reflect.DeepEqual(acceptSet, findAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, searchOptions{})) || trace.halting != nil {
+		t.FailNow()
+	}
+	//every config but the initial one is introduced once and the last change of each config leaves its final bounds
+	introduced := map[config]int{}
+	last := map[config]bounds{}
+	widened := false
+	for _, step := range trace.steps {
+		if step.before == nil {
+			introduced[step.config] += 1
+		}
+		last[step.config] = step.after
+		widened = widened || step.widenedLower || step.widenedUpper
+	}
+	if len(introduced) != len(acceptSet)-1 || !widened {
+		t.Fail()
+	}
+	for config, count := range introduced {
+		if count != 1 || !reflect.DeepEqual(last[config], acceptSet[config]) {
+			t.Error(config)
+		}
+	}
+
+	//without weights the unbounded counter can't be tracked and the search runs into the halting config
+	leftWFA, _ = parseWFA("0,0;2,0_1,0;1,0_3,0;2,0_1,0;2,0")
+	rightWFA, _ = parseWFA("0,0;1,0_0,0;1,0")
+	trace = derivationTrace{}
+	traceAcceptSet(tm, leftWFA, rightWFA, deriveSpecialSets(leftWFA), deriveSpecialSets(rightWFA), searchOptions{}, &trace)
+	if trace.halting == nil || *trace.halting != (config{A, 1, 0, 0}) {
+		t.FailNow()
+	}
+	chain := trace.chain(*trace.halting)
+	if len(chain) == 0 || chain[0].predecessor != (config{A, 0, 0, 0}) || chain[len(chain)-1].config != *trace.halting {
+		t.Fail()
+	}
+	for i := 1; i < len(chain); i++ {
+		if chain[i].predecessor != chain[i-1].config {
+			t.Fail()
+		}
+	}
+}
+
+func TestTraceWidenedGapsAndCongruence(t *testing.T) {
+	tm, _ := parseTM("1RB---_0RC1RC_1RD1RB_1LE1LD_0RA0LE")
+	leftWFA, _ := parseWFA("0,0;2,0_1,0;1,0_3,1;2,0_1,0;2,0")
+	rightWFA, _ := parseWFA("0,0;1,-1_0,0;1,0")
+	widened := func(options searchOptions) (gaps, congruence int) {
+		trace := derivationTrace{}
+		traceAcceptSet(tm, leftWFA, rightWFA, deriveSpecialSets(leftWFA), deriveSpecialSets(rightWFA), options, &trace)
+		for _, step := range trace.steps {
+			if step.widenedGaps {
+				gaps += 1
+			}
+			if step.widenedCongruence {
+				congruence += 1
+			}
+		}
+		return
+	}
+	//a single interval has to close every gap between the weight sums, enough intervals never do
+	if gaps, congruence := widened(searchOptions{intervals: 1}); gaps == 0 || congruence != 0 {
+		t.Error(gaps, congruence)
+	}
+	if gaps, _ := widened(searchOptions{intervals: 1000}); gaps != 0 {
+		t.Error(gaps)
+	}
+	if _, congruence := widened(searchOptions{intervals: 1, congruences: true}); congruence == 0 {
+		t.Error(congruence)
+	}
+}
@@ -1,12 +1,17 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type dwfa struct {
 	states      int
 	symbols     int
 	startState  wfaState
 	transitions map[wfaState]map[symbol]wfaTransition
+	//weights are added modulo this value. 0 means plain integer weights
+	modulus weight
 }
 type wfaState int
 type wfaTransition struct {
@@ -24,9 +29,86 @@ func check(w weight) {
 	}
 }
 
+//the representative of value modulo modulus, where everything is its own representative modulo 0
+func reduce(value, modulus weight) weight {
+	if modulus == 0 {
+		return value
+	}
+	return mod(value, modulus)
+}
+
+//the representative of value in [0, modulus)
+func mod(value, modulus weight) weight {
+	result := value % modulus
+	if result < 0 {
+		result += modulus
+	}
+	return result
+}
+
 type specialSets struct {
 	nonNegative set[wfaState]
 	nonPositive set[wfaState]
+	//if present this replaces the sign sets above with the interval of weights reachable from the start state.
+	//states missing from the map can't be reached from the start state at all.
+	intervals map[wfaState]bounds
+	//for modular wfa: the residues of the weight reachable in each state. Again missing states are unreachable.
+	residues map[wfaState]set[weight]
+}
+
+//returns the bounds of the weight the wfa can have accumulated when it is in the given state.
+//the second value is false if the state can't be reached
+func (s specialSets) stateBounds(state wfaState) (bounds, bool) {
+	if s.intervals != nil {
+		stateBounds, ok := s.intervals[state]
+		return stateBounds, ok
+	}
+	result := bounds{}
+	if s.nonNegative.contains(state) {
+		result[LOWER] = 0
+	}
+	if s.nonPositive.contains(state) {
+		result[UPPER] = 0
+	}
+	return result, true
+}
+
+//returns the residues of the weight sum of both wfa in the given states.
+//the second value is false if this combination of states can't be reached
+func sumOfStateResidues(leftSpecialSets, rightSpecialSets specialSets, leftState, rightState wfaState, modulus weight) (set[weight], bool) {
+	leftResidues, leftOk := leftSpecialSets.residues[leftState]
+	rightResidues, rightOk := rightSpecialSets.residues[rightState]
+	if !leftOk || !rightOk {
+		return nil, false
+	}
+	result := set[weight]{}
+	for leftResidue := range leftResidues {
+		for rightResidue := range rightResidues {
+			result.add(mod(leftResidue+rightResidue, modulus))
+		}
+	}
+	return result, true
+}
+
+//returns the bounds of the weight sum of both wfa in the given states.
+//the second value is false if this combination of states can't be reached
+func sumOfStateBounds(leftSpecialSets, rightSpecialSets specialSets, leftState, rightState wfaState) (bounds, bool) {
+	leftBounds, leftOk := leftSpecialSets.stateBounds(leftState)
+	rightBounds, rightOk := rightSpecialSets.stateBounds(rightState)
+	if !leftOk || !rightOk {
+		return nil, false
+	}
+	result := bounds{}
+	for _, bound := range []boundType{LOWER, UPPER} {
+		leftBound, leftExists := leftBounds[bound]
+		rightBound, rightExists := rightBounds[bound]
+		if leftExists && rightExists {
+			sum := leftBound + rightBound
+			check(sum)
+			result[bound] = sum
+		}
+	}
+	return result, true
 }
 
 type turingMachine struct {
@@ -87,10 +169,70 @@ type config struct {
 
 type bounds map[boundType]weight
 
-type boundType bool
+type boundType int
+
+const LOWER boundType = 0
+const UPPER boundType = 1
 
-const LOWER boundType = false
-const UPPER boundType = true
+//optional congruence: the weight sum has to be RESIDUE modulo MODULUS.
+//while searching for an accept set a MODULUS of 0 is used for sums that are known exactly.
+const MODULUS boundType = 2
+const RESIDUE boundType = 3
+
+//a union of disjoint intervals is stored as its hull [LOWER, UPPER] together with the gaps between the intervals.
+//the i-th gap excludes the weights from gapLower(i) to gapUpper(i)
+func gapLower(i int) boundType {
+	return boundType(4 + 2*i)
+}
+func gapUpper(i int) boundType {
+	return boundType(5 + 2*i)
+}
+
+type gap struct {
+	lower weight
+	upper weight
+}
+
+//the gaps of the bounds in ascending order
+func (b bounds) gaps() []gap {
+	result := []gap{}
+	for i := 0; ; i++ {
+		lower, ok := b[gapLower(i)]
+		if !ok {
+			return result
+		}
+		result = append(result, gap{lower, b[gapUpper(i)]})
+	}
+}
+
+//the disjoint intervals of the bounds, ignoring any congruence
+func (b bounds) intervals() []bounds {
+	result := []bounds{}
+	current := bounds{}
+	if lowerbound, ok := b[LOWER]; ok {
+		current[LOWER] = lowerbound
+	}
+	for _, gap := range b.gaps() {
+		current[UPPER] = gap.lower - 1
+		result = append(result, current)
+		current = bounds{LOWER: gap.upper + 1}
+	}
+	if upperbound, ok := b[UPPER]; ok {
+		current[UPPER] = upperbound
+	}
+	return append(result, current)
+}
+
+func (b bounds) setGaps(gaps []gap) {
+	for i := len(b.gaps()) - 1; i >= 0; i-- {
+		delete(b, gapLower(i))
+		delete(b, gapUpper(i))
+	}
+	for i, gap := range gaps {
+		b[gapLower(i)] = gap.lower
+		b[gapUpper(i)] = gap.upper
+	}
+}
 
 type configWithWeight struct {
 	config
@@ -116,6 +258,7 @@ func copyWFA(oldWFA dwfa) dwfa {
 		symbols:     oldWFA.symbols,
 		startState:  oldWFA.startState,
 		transitions: map[wfaState]map[symbol]wfaTransition{},
+		modulus:     oldWFA.modulus,
 	}
 	for state, tmp := range oldWFA.transitions {
 		newWFA.transitions[state] = map[symbol]wfaTransition{}
@@ -142,6 +285,9 @@ func (wfa dwfa) String() string {
 		}
 		result = result[:len(result)-1] + "_"
 	}
+	if wfa.modulus != 0 {
+		return fmt.Sprintf("%v%%%v", result[1:len(result)-1], wfa.modulus)
+	}
 	return result[1 : len(result)-1]
 }
 
@@ -176,23 +322,81 @@ func (s set[T]) String() string {
 }
 
 func (s specialSets) String() string {
+	if s.residues != nil {
+		states := []wfaState{}
+		for state := range s.residues {
+			states = append(states, state)
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+		result := ""
+		for _, state := range states {
+			result += fmt.Sprintf("_%v%%%v", state, sortedResidues(s.residues[state]))
+		}
+		if len(result) == 0 {
+			return ""
+		}
+		return result[1:]
+	}
+	if s.intervals != nil {
+		states := []wfaState{}
+		for state := range s.intervals {
+			states = append(states, state)
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+		if len(states) == 0 {
+			return ""
+		}
+		result := ""
+		for _, state := range states {
+			result += fmt.Sprintf("_%v:%v", state, s.intervals[state])
+		}
+		return result[1:]
+	}
 	return fmt.Sprintf("%v_%v", s.nonNegative, s.nonPositive)
 }
 
+//"0,2,5"
+func sortedResidues(residues set[weight]) string {
+	values := []weight{}
+	for residue := range residues {
+		values = append(values, residue)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	result := ""
+	for _, value := range values {
+		result += fmt.Sprintf(",%v", value)
+	}
+	if len(result) == 0 {
+		return ""
+	}
+	return result[1:]
+}
+
+func (b bounds) String() string {
+	result := ""
+	for _, bound := range []boundType{LOWER, UPPER} {
+		if value, ok := b[bound]; ok {
+			result += fmt.Sprintf(",%v", value)
+		} else {
+			result += ",-"
+		}
+	}
+	if modulus, ok := b[MODULUS]; ok {
+		result += fmt.Sprintf(",%vmod%v", b[RESIDUE], modulus)
+	}
+	for _, gap := range b.gaps() {
+		result += fmt.Sprintf(",~%v;%v", gap.lower, gap.upper)
+	}
+	return result[1:]
+}
+
 func (as acceptSet) String() string {
 	if len(as) == 0 {
 		return ""
 	}
 	result := ""
 	for config, bounds := range as {
-		result += fmt.Sprintf("_%v", config)
-		for _, bound := range []boundType{LOWER, UPPER} {
-			if value, ok := bounds[bound]; ok {
-				result += fmt.Sprintf(",%v", value)
-			} else {
-				result += ",-"
-			}
-		}
+		result += fmt.Sprintf("_%v,%v", config, bounds)
 	}
 	return result[1:]
 }
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//a wfa with weights in Z^k. Each coordinate is stored as its own dwfa, they all share the same transition structure.
+type vectorWFA []dwfa
+
+type weightVector []weight
+
+type vectorSpecialSets []specialSets
+
+//an accepted box of weight sums: one coordinateBounds per coordinate
+type box []coordinateBounds
+
+//the accepted weight sums of a single coordinate. Modular coordinates list the accepted residues instead of bounds.
+type coordinateBounds struct {
+	bounds   bounds
+	residues set[weight]
+}
+
+type vectorAcceptSet map[config]polyhedron
+
+type configWithWeightVector struct {
+	config
+	weightVector
+}
+
+//the first coordinate keeps the weights of the given wfa, all other coordinates start out unweighted.
+//moduli are given per coordinate, missing values mean plain integer weights.
+func newVectorWFA(wfa dwfa, dimensions int, moduli []weight) vectorWFA {
+	result := vectorWFA{}
+	for i := 0; i < dimensions; i++ {
+		newWFA := copyWFA(wfa)
+		if i > 0 {
+			for _, tmp := range newWFA.transitions {
+				for symbol, transition := range tmp {
+					tmp[symbol] = wfaTransition{transition.wfaState, 0}
+				}
+			}
+		}
+		if i < len(moduli) {
+			newWFA.modulus = moduli[i]
+		}
+		result = append(result, newWFA)
+	}
+	return result
+}
+
+//tracks the weights of each side separately in addition to their sum: for k coordinates the result has 3k,
+//such that the weight sum of the result is (leftWeight+rightWeight, leftWeight, rightWeight)
+func splitSides(leftWFA, rightWFA vectorWFA) (vectorWFA, vectorWFA) {
+	zeroWeights := func(wfa dwfa) dwfa {
+		newWFA := copyWFA(wfa)
+		for _, tmp := range newWFA.transitions {
+			for symbol, transition := range tmp {
+				tmp[symbol] = wfaTransition{transition.wfaState, 0}
+			}
+		}
+		return newWFA
+	}
+	newLeftWFA := copyVectorWFA(leftWFA)
+	newRightWFA := copyVectorWFA(rightWFA)
+	for i := range leftWFA {
+		newLeftWFA = append(newLeftWFA, copyWFA(leftWFA[i]))
+		newRightWFA = append(newRightWFA, zeroWeights(rightWFA[i]))
+	}
+	for i := range rightWFA {
+		newLeftWFA = append(newLeftWFA, zeroWeights(leftWFA[i]))
+		newRightWFA = append(newRightWFA, copyWFA(rightWFA[i]))
+	}
+	return newLeftWFA, newRightWFA
+}
+
+func (wfa vectorWFA) moduli() []weight {
+	result := []weight{}
+	for _, coordinate := range wfa {
+		result = append(result, coordinate.modulus)
+	}
+	return result
+}
+
+func copyVectorWFA(oldWFA vectorWFA) vectorWFA {
+	result := vectorWFA{}
+	for _, coordinate := range oldWFA {
+		result = append(result, copyWFA(coordinate))
+	}
+	return result
+}
+
+func addVectorWFAMemory(oldWFA vectorWFA) vectorWFA {
+	result := vectorWFA{}
+	for _, coordinate := range oldWFA {
+		result = append(result, addWFAMemory(coordinate))
+	}
+	return result
+}
+
+func deriveVectorSpecialSets(wfa vectorWFA) vectorSpecialSets {
+	result := vectorSpecialSets{}
+	for _, coordinate := range wfa {
+		result = append(result, deriveSpecialSets(coordinate))
+	}
+	return result
+}
+
+//the weight sum of every coordinate has to lie within the sum of the state bounds (or residues) of that coordinate
+func sumOfVectorStateBounds(leftSpecialSets, rightSpecialSets vectorSpecialSets, moduli []weight, leftState, rightState wfaState) (box, bool) {
+	result := box{}
+	for i := range leftSpecialSets {
+		if moduli[i] > 0 {
+			hardResidues, reachable := sumOfStateResidues(leftSpecialSets[i], rightSpecialSets[i], leftState, rightState, moduli[i])
+			if !reachable {
+				return nil, false
+			}
+			result = append(result, coordinateBounds{residues: hardResidues})
+			continue
+		}
+		hardBounds, reachable := sumOfStateBounds(leftSpecialSets[i], rightSpecialSets[i], leftState, rightState)
+		if !reachable {
+			return nil, false
+		}
+		result = append(result, coordinateBounds{bounds: hardBounds})
+	}
+	return result, true
+}
+
+func initialBox(moduli []weight) box {
+	result := box{}
+	for _, modulus := range moduli {
+		if modulus > 0 {
+			result = append(result, coordinateBounds{residues: set[weight]{0: {}}})
+		} else {
+			result = append(result, coordinateBounds{bounds: bounds{LOWER: 0, UPPER: 0}})
+		}
+	}
+	return result
+}
+
+func nextConfigsWithWeightVectorChange(oldConfig config, tm turingMachine, leftWFA, rightWFA vectorWFA) []configWithWeightVector {
+	changes := map[config]weightVector{}
+	for i := range leftWFA {
+		for _, nextConfigWithWeightChange := range nextConfigsWithWeightChange(oldConfig, tm, leftWFA[i], rightWFA[i]) {
+			nextConfig := nextConfigWithWeightChange.config
+			if _, ok := changes[nextConfig]; !ok {
+				changes[nextConfig] = make(weightVector, len(leftWFA))
+			}
+			changes[nextConfig][i] = nextConfigWithWeightChange.weight
+		}
+	}
+	result := []configWithWeightVector{}
+	for nextConfig, change := range changes {
+		result = append(result, configWithWeightVector{nextConfig, change})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return fmt.Sprint(result[i].config) < fmt.Sprint(result[j].config)
+	})
+	return result
+}
+
+//shifts every coordinate by its change and clips it to the hard bounds. returns false if one of the coordinates is left empty.
+func shiftAndClipBox(oldBox box, change weightVector, hardBox box, moduli []weight) (box, bool) {
+	result := box{}
+	for i := range oldBox {
+		if moduli[i] > 0 {
+			nextResidues := set[weight]{}
+			for residue := range oldBox[i].residues {
+				nextResidue := mod(residue+change[i], moduli[i])
+				if hardBox[i].residues.contains(nextResidue) {
+					nextResidues.add(nextResidue)
+				}
+			}
+			if len(nextResidues) == 0 {
+				return nil, false
+			}
+			result = append(result, coordinateBounds{residues: nextResidues})
+			continue
+		}
+		nextBounds, nonEmpty := clipBounds(shiftBounds(oldBox[i].bounds, change[i]), hardBox[i].bounds)
+		if !nonEmpty {
+			return nil, false
+		}
+		result = append(result, coordinateBounds{bounds: nextBounds})
+	}
+	return result, true
+}
+
+//widens the accepted box in place to include the next box, see joinBounds
+func joinBox(acceptBox, nextBox, hardBox box, moduli []weight) bool {
+	change := false
+	for i := range acceptBox {
+		if moduli[i] > 0 {
+			for residue := range nextBox[i].residues {
+				if !acceptBox[i].residues.contains(residue) {
+					acceptBox[i].residues.add(residue)
+					change = true
+				}
+			}
+			continue
+		}
+		if joinBounds(acceptBox[i].bounds, nextBox[i].bounds, hardBox[i].bounds, 1) {
+			change = true
+		}
+	}
+	return change
+}
+
+func boxIncludesBox(outer, inner box, moduli []weight) bool {
+	for i := range outer {
+		if moduli[i] > 0 {
+			for residue := range inner[i].residues {
+				if !outer[i].residues.contains(residue) {
+					return false
+				}
+			}
+			continue
+		}
+		if !boundsIncludeBounds(outer[i].bounds, inner[i].bounds) {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------------------------
+
+//templates are the coefficients of linear constraints that get bounded in addition to the box of each config
+func findVectorAcceptSet(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, templates []weightVector) vectorAcceptSet {
+	moduli := leftWFA.moduli()
+	initialConfig := config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA[0].startState, rightWFA[0].startState}
+	todo := []config{initialConfig}
+	initialPolyhedron := polyhedron{box: initialBox(moduli)}
+	for _, coefficients := range templates {
+		initialPolyhedron.constraints = append(initialPolyhedron.constraints, linearConstraint{coefficients, bounds{LOWER: 0, UPPER: 0}})
+	}
+	result := vectorAcceptSet{initialConfig: initialPolyhedron}
+
+	for len(todo) > 0 {
+		currentConfig := todo[0]
+		//not a copy: like in findAcceptSet, joins into the current config are seen by its later next configs
+		currentPolyhedron := result[currentConfig]
+		todo = todo[1:]
+
+		nextConfigs := nextConfigsWithWeightVectorChange(currentConfig, tm, leftWFA, rightWFA)
+		if len(nextConfigs) == 0 {
+			return vectorAcceptSet{}
+		}
+		for _, nextConfigWithWeightVectorChange := range nextConfigs {
+			nextConfig := nextConfigWithWeightVectorChange.config
+			hardBox, reachable := sumOfVectorStateBounds(leftSpecialSets, rightSpecialSets, moduli, nextConfig.leftState, nextConfig.rightState)
+			if !reachable {
+				continue
+			}
+			nextBox, nonEmpty := shiftAndClipBox(currentPolyhedron.box, nextConfigWithWeightVectorChange.weightVector, hardBox, moduli)
+			if !nonEmpty {
+				continue
+			}
+			nextConstraints, nonEmpty := shiftAndClipConstraints(currentPolyhedron.constraints, nextConfigWithWeightVectorChange.weightVector, hardBox)
+			if !nonEmpty {
+				continue
+			}
+			acceptPolyhedron, ok := result[nextConfig]
+			if !ok {
+				result[nextConfig] = polyhedron{nextBox, nextConstraints}
+				todo = append(todo, nextConfig)
+				continue
+			}
+			boxChanged := joinBox(acceptPolyhedron.box, nextBox, hardBox, moduli)
+			if joinConstraints(acceptPolyhedron.constraints, nextConstraints, hardBox) || boxChanged {
+				todo = append(todo, nextConfig)
+			}
+		}
+	}
+	return result
+}
+
+func recursiveVectorWeightAdder(tm turingMachine, leftWFA, rightWFA vectorWFA, currenWeightPairs, usedCoordinates, maxWeightPairs, addedMemoryLeft, addedMemoryRight int, options searchOptions, printMode int) bool {
+	if !options.candidates.isNew(leftWFA, rightWFA, currenWeightPairs, usedCoordinates, addedMemoryLeft, addedMemoryRight) {
+		return false
+	}
+	tryLeftWFA := liftVectorWFA(leftWFA, addedMemoryLeft, options)
+	tryRightWFA := liftVectorWFA(rightWFA, addedMemoryRight, options)
+	if options.splitSides {
+		tryLeftWFA, tryRightWFA = splitSides(tryLeftWFA, tryRightWFA)
+	}
+	leftSpecialSets := deriveVectorSpecialSets(tryLeftWFA)
+	rightSpecialSets := deriveVectorSpecialSets(tryRightWFA)
+	var templates []weightVector
+	if options.polyhedral {
+		templates = octagonTemplates(tryLeftWFA.moduli())
+	}
+	acceptSet := findVectorAcceptSet(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, templates)
+	if len(acceptSet) > 0 && MITMVectorWFARverifier(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		return vectorSimulationCheck(tm, tryLeftWFA, tryRightWFA, acceptSet, options.simulationSteps) &&
+			options.proofs.addVector(printMode, tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+	}
+	options.statistics.rejectedVectorAcceptSet(tm, tryLeftWFA, tryRightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+	if currenWeightPairs >= maxWeightPairs {
+		return false
+	}
+	//coordinates are interchangeable and can be negated, so a new pair only ever goes into the first unused coordinate
+	//and the first pair of a coordinate is always {1, -1}
+	maxCoordinate := usedCoordinates
+	if maxCoordinate >= len(leftWFA) {
+		maxCoordinate = len(leftWFA) - 1
+	}
+	for coordinate := 0; coordinate <= maxCoordinate; coordinate++ {
+		nextUsedCoordinates := usedCoordinates
+		weightPermutations := [][2]weight{{1, -1}}
+		if coordinate == usedCoordinates {
+			nextUsedCoordinates += 1
+		} else {
+			weightPermutations = append(weightPermutations, [2]weight{-1, 1})
+		}
+		for _, weights := range weightPermutations {
+			for leftState, tmpLeft := range leftWFA[coordinate].transitions {
+				for leftSymbol, leftTransition := range tmpLeft {
+					if leftTransition.wfaState == 1 || (leftState == 0 && leftSymbol == 0) {
+						continue
+					}
+					newLeftWFA := copyVectorWFA(leftWFA)
+					newLeftWFA[coordinate].transitions[leftState][leftSymbol] = wfaTransition{leftTransition.wfaState, leftTransition.weight + weights[0]}
+					for rightState, tmpRight := range rightWFA[coordinate].transitions {
+						for rightSymbol, rightTransition := range tmpRight {
+							if rightTransition.wfaState == 1 || (rightState == 0 && rightSymbol == 0) {
+								continue
+							}
+							newRightWFA := copyVectorWFA(rightWFA)
+							newRightWFA[coordinate].transitions[rightState][rightSymbol] = wfaTransition{rightTransition.wfaState, rightTransition.weight + weights[1]}
+							if recursiveVectorWeightAdder(tm, newLeftWFA, newRightWFA, currenWeightPairs+1, nextUsedCoordinates, maxWeightPairs, addedMemoryLeft, addedMemoryRight, options, printMode) {
+								return true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------------------------
+
+func MITMVectorWFARverifier(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet, printMode int) bool {
+	result := verifyCoherentVectorDefinitions(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet) &&
+		verifyCoordinateInvariants(leftWFA, rightWFA, leftSpecialSets, rightSpecialSets) &&
+		verifyVectorStartConfigAccept(leftWFA, rightWFA, acceptSet) &&
+		verifyNoHaltingVectorConfigAccepted(tm, acceptSet) &&
+		verifyVectorForwardClosed(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
+	if result && printMode >= 0 {
+		fmt.Print(certificateString(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet))
+	}
+	return result
+}
+
+func verifyCoherentVectorDefinitions(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) bool {
+	dimensions := len(leftWFA)
+	if dimensions == 0 || len(rightWFA) != dimensions || len(leftSpecialSets) != dimensions || len(rightSpecialSets) != dimensions {
+		return false
+	}
+	if !verifyValidTM(tm) || !verifySharedStructure(leftWFA) || !verifySharedStructure(rightWFA) {
+		return false
+	}
+	for i := 0; i < dimensions; i++ {
+		if leftWFA[i].modulus != rightWFA[i].modulus ||
+			!verifyDeterministicWFA(leftWFA[i]) ||
+			!verifyDeterministicWFA(rightWFA[i]) ||
+			!verifySymbolCompatibility(tm, leftWFA[i], rightWFA[i]) ||
+			!verifySpecialSetsAreSubsets(leftWFA[i], leftSpecialSets[i]) ||
+			!verifySpecialSetsAreSubsets(rightWFA[i], rightSpecialSets[i]) {
+			return false
+		}
+	}
+	moduli := leftWFA.moduli()
+	for config, polyhedron := range acceptSet {
+		if len(polyhedron.box) != dimensions || !verifyConfigIsValid(tm, leftWFA[0], rightWFA[0], config) {
+			return false
+		}
+		for i, coordinateBounds := range polyhedron.box {
+			if !verifyCoordinateBoundsAreValid(coordinateBounds, leftWFA[i].modulus) {
+				return false
+			}
+		}
+		for _, constraint := range polyhedron.constraints {
+			if !verifyConstraintIsValid(constraint, moduli) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//constraints may only combine integer coordinates
+func verifyConstraintIsValid(constraint linearConstraint, moduli []weight) bool {
+	if len(constraint.coefficients) != len(moduli) {
+		return false
+	}
+	for i, coefficient := range constraint.coefficients {
+		if coefficient != 0 && moduli[i] != 0 {
+			return false
+		}
+	}
+	if _, ok := constraint.bounds[MODULUS]; ok || len(constraint.bounds.gaps()) > 0 {
+		return false
+	}
+	return verifyBoundsAreValid(constraint.bounds)
+}
+
+//modular coordinates have only residues, all others only bounds
+func verifyCoordinateBoundsAreValid(coordinateBounds coordinateBounds, modulus weight) bool {
+	if modulus > 0 {
+		return len(coordinateBounds.bounds) == 0 && coordinateBounds.residues != nil && verifyResiduesAreValid(coordinateBounds.residues, modulus)
+	}
+	return coordinateBounds.residues == nil && verifyBoundsAreValid(coordinateBounds.bounds)
+}
+
+func verifyCoordinateInvariants(leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets) bool {
+	for i := range leftWFA {
+		if !verifyLeadingBlankInvariant(leftWFA[i]) ||
+			!verifyLeadingBlankInvariant(rightWFA[i]) ||
+			!verifySpecialSetsHaveClaimedProperty(leftWFA[i], leftSpecialSets[i]) ||
+			!verifySpecialSetsHaveClaimedProperty(rightWFA[i], rightSpecialSets[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+//all coordinates have to describe the same automaton, only the weights may differ
+func verifySharedStructure(wfa vectorWFA) bool {
+	for _, coordinate := range wfa[1:] {
+		if coordinate.states != wfa[0].states || coordinate.symbols != wfa[0].symbols || coordinate.startState != wfa[0].startState {
+			return false
+		}
+		for i := 0; i < wfa[0].states; i++ {
+			for j := 0; j < wfa[0].symbols; j++ {
+				transition, ok := coordinate.transitions[wfaState(i)][symbol(j)]
+				baseTransition, baseOk := wfa[0].transitions[wfaState(i)][symbol(j)]
+				if ok != baseOk || transition.wfaState != baseTransition.wfaState {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func verifyVectorStartConfigAccept(leftWFA, rightWFA vectorWFA, acceptSet vectorAcceptSet) bool {
+	polyhedron, ok := acceptSet[config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA[0].startState, rightWFA[0].startState}]
+	return ok && polyhedronContains(polyhedron, make(weightVector, len(polyhedron.box)))
+}
+
+func verifyNoHaltingVectorConfigAccepted(tm turingMachine, acceptSet vectorAcceptSet) bool {
+	for condition := range acceptSet {
+		if condition.tmState < 0 || int(condition.tmState) >= tm.states {
+			return false
+		}
+		if haltsNextStep(tm, condition.tmState, condition.tmSymbol) {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyVectorForwardClosed(tm turingMachine, leftWFA, rightWFA vectorWFA, leftSpecialSets, rightSpecialSets vectorSpecialSets, acceptSet vectorAcceptSet) bool {
+	moduli := leftWFA.moduli()
+	for config, polyhedron := range acceptSet {
+		for _, nextConfigWithWeightVectorChange := range nextConfigsWithWeightVectorChange(config, tm, leftWFA, rightWFA) {
+			if !nextConfigWithWeightVectorChangeIsAccepted(nextConfigWithWeightVectorChange, polyhedron, leftSpecialSets, rightSpecialSets, moduli, acceptSet) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func nextConfigWithWeightVectorChangeIsAccepted(nextConfigWithWeightVectorChange configWithWeightVector, currentPolyhedron polyhedron, leftSpecialSets, rightSpecialSets vectorSpecialSets, moduli []weight, acceptSet vectorAcceptSet) bool {
+	nextConfig := nextConfigWithWeightVectorChange.config
+	hardBox, reachable := sumOfVectorStateBounds(leftSpecialSets, rightSpecialSets, moduli, nextConfig.leftState, nextConfig.rightState)
+	if !reachable {
+		return true
+	}
+	nextBox, nonEmpty := shiftAndClipBox(currentPolyhedron.box, nextConfigWithWeightVectorChange.weightVector, hardBox, moduli)
+	if !nonEmpty {
+		return true
+	}
+	nextPolyhedron := polyhedron{nextBox, shiftConstraints(currentPolyhedron.constraints, nextConfigWithWeightVectorChange.weightVector)}
+	acceptPolyhedron, ok := acceptSet[nextConfig]
+	return ok && polyhedronIncludesPolyhedron(acceptPolyhedron, nextPolyhedron, moduli)
+}
+
+//------------------------------------------------------------------------------------------------
+
+func (wfa vectorWFA) String() string {
+	if len(wfa) == 0 || wfa[0].states == 0 {
+		return ""
+	}
+	result := "_"
+	for i := 0; i < wfa[0].states; i++ {
+		for j := 0; j < wfa[0].symbols; j++ {
+			transition, ok := wfa[0].transitions[wfaState(i)][symbol(j)]
+			if !ok {
+				result += "-" + strings.Repeat(",-", len(wfa)) + ";"
+				continue
+			}
+			result += fmt.Sprint(transition.wfaState)
+			for _, coordinate := range wfa {
+				result += fmt.Sprintf(",%v", coordinate.transitions[wfaState(i)][symbol(j)].weight)
+			}
+			result += ";"
+		}
+		result = result[:len(result)-1] + "_"
+	}
+	result = result[1 : len(result)-1]
+	for _, modulus := range wfa.moduli() {
+		if modulus != 0 {
+			moduli := fmt.Sprint(wfa.moduli())
+			return result + "%" + strings.ReplaceAll(moduli[1:len(moduli)-1], " ", ",")
+		}
+	}
+	return result
+}
+
+func (s vectorSpecialSets) String() string {
+	result := []string{}
+	for _, coordinate := range s {
+		result = append(result, coordinate.String())
+	}
+	return strings.Join(result, "|")
+}
+
+func (b box) String() string {
+	result := []string{}
+	for _, coordinateBounds := range b {
+		result = append(result, coordinateBounds.String())
+	}
+	return strings.Join(result, ",")
+}
+
+//"-3,-" or "%0;2" for the residues of a modular coordinate
+func (c coordinateBounds) String() string {
+	if c.residues != nil {
+		return "%" + strings.ReplaceAll(sortedResidues(c.residues), ",", ";")
+	}
+	return c.bounds.String()
+}
+
+func (as vectorAcceptSet) String() string {
+	if len(as) == 0 {
+		return ""
+	}
+	result := ""
+	for config, polyhedron := range as {
+		result += fmt.Sprintf("_%v,%v", config, polyhedron)
+	}
+	return result[1:]
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func exampleVectorTM() turingMachine {
+	return turingMachine{
+		states:  2,
+		symbols: 2,
+		transitions: map[tmState]map[symbol]tmTransition{
+			A: {0: {1, R, B},
+				1: {1, L, A}},
+			B: {0: {0, L, A},
+				1: {0, R, B}},
+		},
+	}
+}
+
+func exampleVectorWFAs() (vectorWFA, vectorWFA) {
+	leftWFA := vectorWFA{
+		{
+			states:     1,
+			symbols:    2,
+			startState: 0,
+			transitions: map[wfaState]map[symbol]wfaTransition{
+				0: {0: {0, 0},
+					1: {0, 1}},
+			},
+		},
+		{
+			states:     1,
+			symbols:    2,
+			startState: 0,
+			transitions: map[wfaState]map[symbol]wfaTransition{
+				0: {0: {0, 0},
+					1: {0, 0}},
+			},
+		},
+	}
+	rightWFA := vectorWFA{
+		{
+			states:     3,
+			symbols:    2,
+			startState: 0,
+			transitions: map[wfaState]map[symbol]wfaTransition{
+				0: {0: {0, 0},
+					1: {1, 0}},
+				1: {0: {2, 0},
+					1: {1, 1}},
+				2: {0: {2, 0},
+					1: {2, 0}},
+			},
+		},
+		{
+			states:     3,
+			symbols:    2,
+			startState: 0,
+			transitions: map[wfaState]map[symbol]wfaTransition{
+				0: {0: {0, 0},
+					1: {1, 0}},
+				1: {0: {2, 0},
+					1: {1, 0}},
+				2: {0: {2, 0},
+					1: {2, 0}},
+			},
+		},
+	}
+	return leftWFA, rightWFA
+}
+
+func TestNextConfigsWithWeightVectorChange(t *testing.T) {
+	tm := exampleVectorTM()
+	leftWFA, rightWFA := exampleVectorWFAs()
+	leftWFA[1].transitions[0][1] = wfaTransition{0, -2}
+	expectedResult := []configWithWeightVector{
+		{config{A, 0, 0, 2}, weightVector{0, 0}},
+		{config{A, 1, 0, 2}, weightVector{-1, 2}},
+	}
+	result := nextConfigsWithWeightVectorChange(config{B, 0, 0, 1}, tm, leftWFA, rightWFA)
+	if !reflect.DeepEqual(expectedResult, result) {
+		t.Fail()
+	}
+}
+
+func TestFindVectorAcceptSet(t *testing.T) {
+	tm := exampleVectorTM()
+	leftWFA, rightWFA := exampleVectorWFAs()
+	leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+	rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+	scalarResult := findAcceptSet(tm, leftWFA[0], rightWFA[0], leftSpecialSets[0], rightSpecialSets[0], searchOptions{})
+
+	result := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+	if len(result) != len(scalarResult) {
+		t.Fail()
+	}
+	for config, scalarBounds := range scalarResult {
+		expectedBox := box{{bounds: scalarBounds}, {bounds: bounds{LOWER: 0, UPPER: 0}}}
+		if !reflect.DeepEqual(expectedBox, result[config].box) {
+			t.Fail()
+		}
+	}
+}
+
+func TestMITMVectorWFARverifier(t *testing.T) {
+	tm := exampleVectorTM()
+	t.Run("CorrectExample", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		if !MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("WrongSecondCoordinate", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		leftWFA[1].transitions[0][1] = wfaTransition{0, 1}
+		leftSpecialSets = deriveVectorSpecialSets(leftWFA)
+		if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("DifferentStructure", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		rightWFA[1].transitions[1][0] = wfaTransition{1, 0}
+		if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("WrongDimension", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		for config, acceptPolyhedron := range acceptSet {
+			acceptSet[config] = polyhedron{box: acceptPolyhedron.box[:1]}
+		}
+		if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+}
+
+func TestMITMVectorWFARdecider(t *testing.T) {
+	tm := turingMachine{
+		states:  5,
+		symbols: 2,
+		transitions: map[tmState]map[symbol]tmTransition{
+			A: {0: {1, R, B}},
+			B: {0: {0, R, C},
+				1: {1, R, C}},
+			C: {0: {1, R, D},
+				1: {1, R, B}},
+			D: {0: {1, L, E},
+				1: {1, L, D}},
+			E: {0: {0, R, A},
+				1: {0, L, E}},
+		},
+	}
+	t.Run("TwoCounters", func(t *testing.T) {
+		if !MITMWFARdecider(tm, 9, 4, 4, 1, 0, 0, searchOptions{dimensions: 2}, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("SplitSides", func(t *testing.T) {
+		if !MITMWFARdecider(tm, 9, 4, 4, 1, 0, 0, searchOptions{splitSides: true}, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("Polyhedral", func(t *testing.T) {
+		if !MITMWFARdecider(tm, 9, 4, 4, 1, 0, 0, searchOptions{splitSides: true, polyhedral: true}, -1) {
+			t.Fail()
+		}
+	})
+}
+
+func TestReachableResidues(t *testing.T) {
+	wfa := dwfa{
+		states:     3,
+		symbols:    2,
+		startState: 0,
+		transitions: map[wfaState]map[symbol]wfaTransition{
+			0: {0: {0, 0},
+				1: {1, 1}},
+			1: {0: {1, 2},
+				1: {2, -1}},
+			2: {0: {2, 0},
+				1: {2, 0}},
+		},
+		modulus: 4,
+	}
+	expectedSets := specialSets{
+		residues: map[wfaState]set[weight]{
+			0: {0: {}},
+			1: {1: {}, 3: {}},
+			2: {0: {}, 2: {}},
+		},
+	}
+	specialSets := deriveSpecialSets(wfa)
+	if !reflect.DeepEqual(expectedSets, specialSets) {
+		t.Fail()
+	}
+	if !verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+		t.Fail()
+	}
+	specialSets.residues[2].remove(2)
+	if verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+		t.Fail()
+	}
+}
+
+func TestModularVectorWFA(t *testing.T) {
+	tm := exampleVectorTM()
+	t.Run("MixedCertificate", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftWFA[1].modulus = 2
+		rightWFA[1].modulus = 2
+		leftWFA[1].transitions[0][1] = wfaTransition{0, 1}
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		if len(acceptSet) == 0 {
+			t.Fail()
+		}
+		for _, polyhedron := range acceptSet {
+			if polyhedron.box[1].residues == nil || polyhedron.box[0].residues != nil {
+				t.Fail()
+			}
+		}
+		if !MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("WrongResidues", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftWFA[1].modulus = 2
+		rightWFA[1].modulus = 2
+		leftWFA[1].transitions[0][1] = wfaTransition{0, 1}
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		for _, polyhedron := range acceptSet {
+			polyhedron.box[1].residues = set[weight]{0: {}}
+		}
+		if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+	t.Run("DifferentModuli", func(t *testing.T) {
+		leftWFA, rightWFA := exampleVectorWFAs()
+		leftWFA[1].modulus = 2
+		rightWFA[1].modulus = 2
+		leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+		rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+		acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+		rightWFA[1].modulus = 3
+		if MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+			t.Fail()
+		}
+	})
+}
+
+func TestSplitSides(t *testing.T) {
+	tm := exampleVectorTM()
+	leftWFA, rightWFA := exampleVectorWFAs()
+	leftWFA, rightWFA = splitSides(leftWFA[:1], rightWFA[:1])
+	if len(leftWFA) != 3 || len(rightWFA) != 3 {
+		t.FailNow()
+	}
+	for _, config := range []config{{A, 0, 0, 0}, {A, 1, 0, 1}, {B, 0, 0, 1}, {B, 1, 0, 1}} {
+		for _, next := range nextConfigsWithWeightVectorChange(config, tm, leftWFA, rightWFA) {
+			change := next.weightVector
+			if change[0] != change[1]+change[2] {
+				t.Fail()
+			}
+		}
+	}
+	leftSpecialSets := deriveVectorSpecialSets(leftWFA)
+	rightSpecialSets := deriveVectorSpecialSets(rightWFA)
+	acceptSet := findVectorAcceptSet(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, nil)
+	if !MITMVectorWFARverifier(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet, -1) {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(acceptSet[config{B, 1, 0, 1}].box[2].bounds, bounds{LOWER: 0}) {
+		t.Fail()
+	}
+}
+
+func TestScalarAcceptSet(t *testing.T) {
+	valid, _ := parseVectorAcceptSet("A,0,0,0,0,0_B,1,0,2,-3,-")
+	acceptSet, ok := scalarAcceptSet(valid)
+	if !ok || !reflect.DeepEqual(acceptSet[config{1, 1, 0, 2}], bounds{LOWER: -3}) {
+		t.Error(acceptSet)
+	}
+	//a second coordinate or residues don't fit plain integer wfa
+	for _, s := range []string{"A,0,0,0,0,0,1,1", "A,0,0,0,%0"} {
+		malformed, err := parseVectorAcceptSet(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := scalarAcceptSet(malformed); ok {
+			t.Error(s)
+		}
+	}
+}
@@ -11,26 +11,30 @@ func MITMWFARverifier(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets,
 		verifyStartConfigAccept(leftWFA, rightWFA, acceptSet) &&
 		verifyNoHaltingConfigAccepted(tm, acceptSet) &&
 		verifyForwardClosed(tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet)
-	if result {
-		if printMode >= 0 {
-			cert := fmt.Sprintln(tm)
-			if printMode >= 1 {
-				cert += fmt.Sprintln(leftWFA)
-				cert += fmt.Sprintln(rightWFA)
-			}
-			if printMode >= 2 {
-				cert += fmt.Sprintln(leftSpecialSets)
-				cert += fmt.Sprintln(rightSpecialSets)
-				cert += fmt.Sprintln(acceptSet)
-			}
-			fmt.Print(cert)
-		}
+	if result && printMode >= 0 {
+		fmt.Print(certificateString(printMode, tm, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet))
 	}
 	return result
 }
 
+//the TM, followed by the wfa for printMode 1 and by the special sets and accept set as well for printMode 2
+func certificateString(printMode int, tm turingMachine, leftWFA, rightWFA, leftSpecialSets, rightSpecialSets, acceptSet fmt.Stringer) string {
+	cert := fmt.Sprintln(tm)
+	if printMode >= 1 {
+		cert += fmt.Sprintln(leftWFA)
+		cert += fmt.Sprintln(rightWFA)
+	}
+	if printMode >= 2 {
+		cert += fmt.Sprintln(leftSpecialSets)
+		cert += fmt.Sprintln(rightSpecialSets)
+		cert += fmt.Sprintln(acceptSet)
+	}
+	return cert
+}
+
 func verifyCoherentDefinitions(tm turingMachine, leftWFA, rightWFA dwfa, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) bool {
 	return verifyValidTM(tm) &&
+		leftWFA.modulus == 0 && rightWFA.modulus == 0 &&
 		verifyDeterministicWFA(leftWFA) &&
 		verifyDeterministicWFA(rightWFA) &&
 		verifySymbolCompatibility(tm, leftWFA, rightWFA) &&
@@ -61,7 +65,7 @@ func verifyValidTM(tm turingMachine) bool {
 }
 
 func verifyDeterministicWFA(wfa dwfa) bool {
-	if wfa.states <= 0 || wfa.symbols <= 0 {
+	if wfa.states <= 0 || wfa.symbols <= 0 || wfa.modulus < 0 {
 		return false
 	}
 	if wfa.startState < 0 || int(wfa.startState) >= wfa.states {
@@ -101,6 +105,19 @@ func verifySymbolCompatibility(tm turingMachine, leftWFA, rightWFA dwfa) bool {
 }
 
 func verifySpecialSetsAreSubsets(wfa dwfa, specialSets specialSets) bool {
+	for state, bounds := range specialSets.intervals {
+		if int(state) < 0 || int(state) >= wfa.states || !verifyBoundsAreValid(bounds) {
+			return false
+		}
+	}
+	if specialSets.residues != nil && wfa.modulus == 0 {
+		return false
+	}
+	for state, residues := range specialSets.residues {
+		if int(state) < 0 || int(state) >= wfa.states || !verifyResiduesAreValid(residues, wfa.modulus) {
+			return false
+		}
+	}
 	for state := range specialSets.nonNegative {
 		if int(state) < 0 || int(state) >= wfa.states {
 			return false
@@ -116,27 +133,76 @@ func verifySpecialSetsAreSubsets(wfa dwfa, specialSets specialSets) bool {
 
 func verifyAcceptSetIsValid(tm turingMachine, leftWFA, rightWFA dwfa, acceptSet acceptSet) bool {
 	for config, bounds := range acceptSet {
-		if int(config.tmState) < 0 || int(config.tmState) >= tm.states {
+		if !verifyConfigIsValid(tm, leftWFA, rightWFA, config) || !verifyBoundsAreValid(bounds) {
 			return false
 		}
-		if int(config.tmSymbol) < 0 || int(config.tmSymbol) >= tm.symbols {
-			return false
+	}
+	return true
+}
+
+func verifyConfigIsValid(tm turingMachine, leftWFA, rightWFA dwfa, config config) bool {
+	if int(config.tmState) < 0 || int(config.tmState) >= tm.states {
+		return false
+	}
+	if int(config.tmSymbol) < 0 || int(config.tmSymbol) >= tm.symbols {
+		return false
+	}
+	if int(config.leftState) < 0 || int(config.leftState) >= leftWFA.states {
+		return false
+	}
+	if int(config.rightState) < 0 || int(config.rightState) >= rightWFA.states {
+		return false
+	}
+	return true
+}
+
+func verifyBoundsAreValid(bounds bounds) bool {
+	lowerbound, lowerExists := bounds[LOWER]
+	if lowerExists {
+		check(lowerbound)
+	}
+	upperbound, upperExists := bounds[UPPER]
+	if upperExists {
+		check(upperbound)
+	}
+	if lowerExists && upperExists && lowerbound > upperbound {
+		return false
+	}
+	modulus, modulusExists := bounds[MODULUS]
+	residue, residueExists := bounds[RESIDUE]
+	if modulusExists != residueExists {
+		return false
+	}
+	if modulusExists && (modulus < 1 || residue < 0 || residue >= modulus) {
+		return false
+	}
+	//the gaps have to be ascending and separated by at least one weight from each other and the ends of the hull
+	gaps := bounds.gaps()
+	knownKeys := 2 * len(gaps)
+	for _, exists := range []bool{lowerExists, upperExists, modulusExists, residueExists} {
+		if exists {
+			knownKeys += 1
 		}
-		if int(config.leftState) < 0 || int(config.leftState) >= leftWFA.states {
+	}
+	if len(bounds) != knownKeys {
+		return false
+	}
+	for i, gap := range gaps {
+		check(gap.lower)
+		check(gap.upper)
+		if gap.lower > gap.upper || (lowerExists && gap.lower <= lowerbound) || (upperExists && gap.upper >= upperbound) {
 			return false
 		}
-		if int(config.rightState) < 0 || int(config.rightState) >= rightWFA.states {
+		if i > 0 && gaps[i-1].upper+1 >= gap.lower {
 			return false
 		}
-		lowerbound, lowerExists := bounds[LOWER]
-		if lowerExists {
-			check(lowerbound)
-		}
-		upperbound, upperExists := bounds[UPPER]
-		if upperExists {
-			check(upperbound)
-		}
-		if lowerExists && upperExists && lowerbound > upperbound {
+	}
+	return true
+}
+
+func verifyResiduesAreValid(residues set[weight], modulus weight) bool {
+	for residue := range residues {
+		if residue < 0 || residue >= modulus {
 			return false
 		}
 	}
@@ -146,10 +212,19 @@ func verifyAcceptSetIsValid(tm turingMachine, leftWFA, rightWFA dwfa, acceptSet
 func verifyLeadingBlankInvariant(wfa dwfa) bool {
 	state := wfa.startState
 	transition := wfa.transitions[state][0]
+	if wfa.modulus > 0 {
+		return transition.wfaState == state && mod(transition.weight, wfa.modulus) == 0
+	}
 	return transition.wfaState == state && transition.weight == 0
 }
 
 func verifySpecialSetsHaveClaimedProperty(wfa dwfa, specialSets specialSets) bool {
+	if wfa.modulus > 0 {
+		return specialSets.residues != nil && verifyResiduesHaveClaimedProperty(wfa, specialSets.residues)
+	}
+	if specialSets.intervals != nil {
+		return verifyIntervalsHaveClaimedProperty(wfa, specialSets.intervals)
+	}
 	for i := 0; i < wfa.states; i++ {
 		for j := 0; j < wfa.symbols; j++ {
 			transition := wfa.transitions[wfaState(i)][symbol(j)]
@@ -188,20 +263,74 @@ func transitionRetainsSpecialSets(startState, endState wfaState, weight weight,
 	return true
 }
 
-func verifyStartConfigAccept(leftWFA, rightWFA dwfa, acceptSet acceptSet) bool {
-	bounds, ok := acceptSet[config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}]
-	if !ok {
+//the intervals have to contain the weight of the start state and be closed under all transitions.
+//states without an interval are claimed to be unreachable, so every transition from an interval has to lead to one.
+func verifyIntervalsHaveClaimedProperty(wfa dwfa, intervals map[wfaState]bounds) bool {
+	startBounds, ok := intervals[wfa.startState]
+	if !ok || !boundsContain(startBounds, 0) {
+		return false
+	}
+	for state, stateBounds := range intervals {
+		for j := 0; j < wfa.symbols; j++ {
+			transition := wfa.transitions[state][symbol(j)]
+			endBounds, ok := intervals[transition.wfaState]
+			if !ok || !boundsIncludeBounds(endBounds, shiftBounds(stateBounds, transition.weight)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//same as for the intervals, but for the residues of modular wfa
+func verifyResiduesHaveClaimedProperty(wfa dwfa, residues map[wfaState]set[weight]) bool {
+	startResidues, ok := residues[wfa.startState]
+	if !ok || !startResidues.contains(0) {
+		return false
+	}
+	for state, stateResidues := range residues {
+		for j := 0; j < wfa.symbols; j++ {
+			transition := wfa.transitions[state][symbol(j)]
+			endResidues, ok := residues[transition.wfaState]
+			if !ok {
+				return false
+			}
+			for residue := range stateResidues {
+				if !endResidues.contains(mod(residue+transition.weight, wfa.modulus)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func boundsContain(bounds bounds, value weight) bool {
+	if lowerbound, ok := bounds[LOWER]; ok && lowerbound > value {
 		return false
 	}
-	if lowerbound, ok := bounds[LOWER]; ok && lowerbound > 0 {
+	if upperbound, ok := bounds[UPPER]; ok && upperbound < value {
 		return false
 	}
-	if upperbound, ok := bounds[UPPER]; ok && upperbound < 0 {
+	if modulus, ok := bounds[MODULUS]; ok && reduce(value, modulus) != bounds[RESIDUE] {
 		return false
 	}
+	for _, gap := range bounds.gaps() {
+		if gap.lower <= value && value <= gap.upper {
+			return false
+		}
+	}
 	return true
 }
 
+func verifyStartConfigAccept(leftWFA, rightWFA dwfa, acceptSet acceptSet) bool {
+	bounds, ok := acceptSet[config{TMSTARTSTATE, TMSTARTSYMBOL, leftWFA.startState, rightWFA.startState}]
+	if !ok {
+		return false
+	}
+	return boundsContain(bounds, 0)
+}
+
 func verifyNoHaltingConfigAccepted(tm turingMachine, acceptSet acceptSet) bool {
 	for condition := range acceptSet {
 		if condition.tmState < 0 || int(condition.tmState) >= tm.states {
@@ -278,59 +407,201 @@ func nextConfigsWithWeightChange(oldConfig config, tm turingMachine, leftWFA, ri
 
 func nextConfigWithWeightChangeIsAccepted(nextConfigWithWeightChange configWithWeight, bounds bounds, leftSpecialSets, rightSpecialSets specialSets, acceptSet acceptSet) bool {
 	nextConfig := nextConfigWithWeightChange.config
-	lowerbound, lowerExists := bounds[LOWER]
-	upperbound, upperExists := bounds[UPPER]
 
-	//adjust bounds according to the change
-	if lowerExists {
-		lowerbound += nextConfigWithWeightChange.weight
+	//adjust bounds according to the weights the wfa states can actually have
+	hardBounds, reachable := sumOfStateBounds(leftSpecialSets, rightSpecialSets, nextConfig.leftState, nextConfig.rightState)
+	if !reachable {
+		return true
 	}
-	if upperExists {
-		upperbound += nextConfigWithWeightChange.weight
+	nextBounds, nonEmpty := clipBounds(shiftBounds(bounds, nextConfigWithWeightChange.weight), hardBounds)
+	if !nonEmpty {
+		return true
+	}
+	return acceptSetCountainsConfigBounds(acceptSet, nextConfig, nextBounds)
+}
+
+func acceptSetCountainsConfigBounds(acceptSet acceptSet, nextConfig config, nextBounds map[boundType]weight) bool {
+	acceptBounds, ok := acceptSet[nextConfig]
+	if !ok {
+		return false
+	}
+	return boundsIncludeBounds(acceptBounds, nextBounds)
+}
+
+func shiftBounds(oldBounds bounds, change weight) bounds {
+	result := bounds{}
+	for bound, value := range oldBounds {
+		switch bound {
+		case MODULUS:
+			result[bound] = value
+		case RESIDUE:
+			check(value + change)
+			result[bound] = reduce(value+change, oldBounds[MODULUS])
+		default:
+			check(value + change)
+			result[bound] = value + change
+		}
+	}
+	return result
+}
+
+//restricts the bounds to the hard bounds. returns false if no weight is left.
+func clipBounds(oldBounds, hardBounds bounds) (bounds, bool) {
+	result := bounds{}
+	for bound, value := range oldBounds {
+		result[bound] = value
+	}
+	if hardLower, ok := hardBounds[LOWER]; ok {
+		if lowerbound, ok := result[LOWER]; !ok || lowerbound < hardLower {
+			result[LOWER] = hardLower
+		}
+	}
+	if hardUpper, ok := hardBounds[UPPER]; ok {
+		if upperbound, ok := result[UPPER]; !ok || upperbound > hardUpper {
+			result[UPPER] = hardUpper
+		}
 	}
+	//without a congruence or gaps every weight of the hull is contained
+	_, congruence := result[MODULUS]
+	_, gaps := result[gapLower(0)]
+	if !congruence && !gaps {
+		lowerbound, lowerExists := result[LOWER]
+		upperbound, upperExists := result[UPPER]
+		return result, !lowerExists || !upperExists || lowerbound <= upperbound
+	}
+	return alignBounds(result)
+}
 
-	//adjust bounds according to the special sets
-	if leftSpecialSets.nonNegative.contains(nextConfig.leftState) && rightSpecialSets.nonNegative.contains(nextConfig.rightState) {
-		if !lowerExists || lowerbound < 0 {
-			lowerExists = true
-			lowerbound = 0
+//moves the bounds inwards to the closest values that satisfy the congruence. returns false if no weight is left.
+func alignBounds(oldBounds bounds) (bounds, bool) {
+	result := bounds{}
+	for bound, value := range oldBounds {
+		result[bound] = value
+	}
+	if modulus, ok := result[MODULUS]; ok && modulus == 0 {
+		residue := result[RESIDUE]
+		if !boundsContain(result, residue) {
+			return result, false
+		}
+		result[LOWER] = residue
+		result[UPPER] = residue
+	}
+	for _, bound := range []boundType{LOWER, UPPER} {
+		if value, ok := result[bound]; ok {
+			direction := weight(1)
+			if bound == UPPER {
+				direction = -1
+			}
+			nearest, nonEmpty := nearestContained(result, value, direction)
+			if !nonEmpty {
+				return result, false
+			}
+			result[bound] = nearest
+		}
+	}
+	//now that the ends aren't in a gap the gaps are either fully inside or fully outside of the hull
+	hull := bounds{}
+	for _, bound := range []boundType{LOWER, UPPER} {
+		if value, ok := result[bound]; ok {
+			hull[bound] = value
 		}
 	}
-	if leftSpecialSets.nonPositive.contains(nextConfig.leftState) && rightSpecialSets.nonPositive.contains(nextConfig.rightState) {
-		if !upperExists || upperbound > 0 {
-			upperExists = true
-			upperbound = 0
+	remainingGaps := []gap{}
+	for _, gap := range result.gaps() {
+		if boundsContain(hull, gap.lower) {
+			remainingGaps = append(remainingGaps, gap)
 		}
 	}
+	result.setGaps(remainingGaps)
+	return result, true
+}
 
-	nextBounds := map[boundType]weight{}
-	if lowerExists {
-		nextBounds[LOWER] = lowerbound
+//the first weight contained in the bounds starting from value and going into the direction (1 or -1).
+//returns false if there is none before the end of the hull
+func nearestContained(bounds bounds, value, direction weight) (weight, bool) {
+	for {
+		if lowerbound, ok := bounds[LOWER]; ok && direction < 0 && value < lowerbound {
+			return value, false
+		}
+		if upperbound, ok := bounds[UPPER]; ok && direction > 0 && value > upperbound {
+			return value, false
+		}
+		if modulus, ok := bounds[MODULUS]; ok && reduce(value, modulus) != bounds[RESIDUE] {
+			if modulus == 0 {
+				if (bounds[RESIDUE]-value)*direction < 0 {
+					return value, false
+				}
+				value = bounds[RESIDUE]
+				continue
+			}
+			value += direction * mod(direction*(bounds[RESIDUE]-value), modulus)
+			check(value)
+			continue
+		}
+		inGap := false
+		for _, gap := range bounds.gaps() {
+			if gap.lower <= value && value <= gap.upper {
+				inGap = true
+				if direction > 0 {
+					value = gap.upper + 1
+				} else {
+					value = gap.lower - 1
+				}
+				break
+			}
+		}
+		if !inGap {
+			return value, true
+		}
 	}
-	if upperExists {
-		nextBounds[UPPER] = upperbound
+}
+
+//no weight of the inner bounds may lie in a gap of the outer bounds
+func gapsInclude(outer, inner bounds) bool {
+	for _, gap := range outer.gaps() {
+		first := gap.lower
+		if lowerbound, ok := inner[LOWER]; ok && lowerbound > first {
+			first = lowerbound
+		}
+		if value, ok := nearestContained(inner, first, 1); ok && value <= gap.upper {
+			return false
+		}
 	}
+	return true
+}
 
-	if upperExists && lowerExists && upperbound < lowerbound {
+//every weight of the inner bounds has to satisfy the congruence of the outer bounds, if there is one.
+//that is the case if the inner congruence is finer or if the inner bounds only contain a single weight.
+func congruenceIncludes(outer, inner bounds) bool {
+	outerModulus, ok := outer[MODULUS]
+	if !ok {
 		return true
 	}
-	return acceptSetCountainsConfigBounds(acceptSet, nextConfig, nextBounds)
+	if innerModulus, ok := inner[MODULUS]; ok && innerModulus%outerModulus == 0 {
+		return reduce(inner[RESIDUE], outerModulus) == outer[RESIDUE]
+	}
+	innerLower, innerLowerExists := inner[LOWER]
+	innerUpper, innerUpperExists := inner[UPPER]
+	return innerLowerExists && innerUpperExists && innerLower == innerUpper && reduce(innerLower, outerModulus) == outer[RESIDUE]
 }
 
-func acceptSetCountainsConfigBounds(acceptSet acceptSet, nextConfig config, nextBounds map[boundType]weight) bool {
-	acceptBounds, ok := acceptSet[nextConfig]
-	if !ok {
+func boundsIncludeBounds(outer, inner bounds) bool {
+	inner, nonEmpty := alignBounds(inner)
+	if !nonEmpty {
+		return true
+	}
+	if !congruenceIncludes(outer, inner) || !gapsInclude(outer, inner) {
 		return false
 	}
-	acceptedLower, acceptedLowerExists := acceptBounds[LOWER]
-	nextLower, nextLowerExists := nextBounds[LOWER]
-	if acceptedLowerExists && (!nextLowerExists || acceptedLower > nextLower) {
+	outerLower, outerLowerExists := outer[LOWER]
+	innerLower, innerLowerExists := inner[LOWER]
+	if outerLowerExists && (!innerLowerExists || outerLower > innerLower) {
 		return false
 	}
 
-	acceptedUpper, acceptedUpperExists := acceptBounds[UPPER]
-	nextUpper, nextUpperExists := nextBounds[UPPER]
-	if acceptedUpperExists && (!nextUpperExists || acceptedUpper < nextUpper) {
+	outerUpper, outerUpperExists := outer[UPPER]
+	innerUpper, innerUpperExists := inner[UPPER]
+	if outerUpperExists && (!innerUpperExists || outerUpper < innerUpper) {
 		return false
 	}
 	return true
@@ -319,6 +319,26 @@ func TestVerifySpecialSetsAreSubsets(t *testing.T) {
 	})
 }
 
+func TestClipBoundsWithGaps(t *testing.T) {
+	oldBounds := bounds{LOWER: 0, gapLower(0): 1, gapUpper(0): 4, gapLower(1): 7, gapUpper(1): 7}
+	result, nonEmpty := clipBounds(oldBounds, bounds{LOWER: 1, UPPER: 6})
+	if !nonEmpty || !reflect.DeepEqual(result, bounds{LOWER: 5, UPPER: 6}) {
+		t.Fail()
+	}
+	_, nonEmpty = clipBounds(oldBounds, bounds{LOWER: 2, UPPER: 4})
+	if nonEmpty {
+		t.Fail()
+	}
+	result, nonEmpty = clipBounds(oldBounds, bounds{UPPER: 8})
+	oldBounds[UPPER] = 8
+	if !nonEmpty || !reflect.DeepEqual(result, oldBounds) {
+		t.Fail()
+	}
+	if result.String() != "0,8,~1;4,~7;7" {
+		t.Fail()
+	}
+}
+
 func TestVerifyAcceptSetIsValid(t *testing.T) {
 	t.Run("OutOfBoundTmState", func(t *testing.T) {
 		tm := turingMachine{states: 2, symbols: 2}
@@ -391,6 +411,24 @@ func TestVerifyAcceptSetIsValid(t *testing.T) {
 		}()
 		verifyAcceptSetIsValid(tm, leftWFA, rightWFA, acceptSet)
 	})
+	t.Run("OverlappingGaps", func(t *testing.T) {
+		tm := turingMachine{states: 2, symbols: 2}
+		leftWFA := dwfa{states: 2}
+		rightWFA := dwfa{states: 2}
+		acceptSet := map[config]bounds{{0, 0, 0, 0}: {LOWER: 0, gapLower(0): 1, gapUpper(0): 4, gapLower(1): 5, gapUpper(1): 6}}
+		if verifyAcceptSetIsValid(tm, leftWFA, rightWFA, acceptSet) {
+			t.Fail()
+		}
+	})
+	t.Run("GapAtBound", func(t *testing.T) {
+		tm := turingMachine{states: 2, symbols: 2}
+		leftWFA := dwfa{states: 2}
+		rightWFA := dwfa{states: 2}
+		acceptSet := map[config]bounds{{0, 0, 0, 0}: {LOWER: 0, UPPER: 4, gapLower(0): 2, gapUpper(0): 4}}
+		if verifyAcceptSetIsValid(tm, leftWFA, rightWFA, acceptSet) {
+			t.Fail()
+		}
+	})
 	t.Run("CorrectAcceptSet", func(t *testing.T) {
 		tm := turingMachine{states: 2, symbols: 2}
 		leftWFA := dwfa{states: 2}
@@ -401,6 +439,7 @@ func TestVerifyAcceptSetIsValid(t *testing.T) {
 			{0, 1, 1, 0}: {LOWER: -3, UPPER: 7},
 			{1, 0, 0, 1}: {UPPER: 0},
 			{1, 1, 1, 1}: {},
+			{1, 1, 0, 0}: {LOWER: 0, gapLower(0): 1, gapUpper(0): 4, gapLower(1): 6, gapUpper(1): 6},
 		}
 		if !verifyAcceptSetIsValid(tm, leftWFA, rightWFA, acceptSet) {
 			t.Fail()
@@ -637,6 +676,54 @@ func TestVerifySpecialSetsHaveClaimedProperty(t *testing.T) {
 	})
 }
 
+func TestVerifyIntervalsHaveClaimedProperty(t *testing.T) {
+	wfa := dwfa{
+		states:     4,
+		symbols:    2,
+		startState: 0,
+		transitions: map[wfaState]map[symbol]wfaTransition{
+			0: {0: {0, 0},
+				1: {1, 1}},
+			1: {0: {2, -1},
+				1: {1, 0}},
+			2: {0: {2, 1},
+				1: {1, 1}},
+			3: {0: {3, -5},
+				1: {0, -5}},
+		},
+	}
+	t.Run("CorrectIntervals", func(t *testing.T) {
+		specialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 0, UPPER: 0}, 1: {LOWER: 1}, 2: {LOWER: 0}}}
+		if !verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+			t.Fail()
+		}
+	})
+	t.Run("StartNotContained", func(t *testing.T) {
+		specialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 1}, 1: {LOWER: 1}, 2: {LOWER: 0}}}
+		if verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+			t.Fail()
+		}
+	})
+	t.Run("NotClosed", func(t *testing.T) {
+		specialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 0, UPPER: 0}, 1: {LOWER: 1}, 2: {LOWER: 1}}}
+		if verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+			t.Fail()
+		}
+	})
+	t.Run("MissingReachableState", func(t *testing.T) {
+		specialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 0, UPPER: 0}, 1: {LOWER: 1}}}
+		if verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+			t.Fail()
+		}
+	})
+	t.Run("UnboundedInterval", func(t *testing.T) {
+		specialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 0, UPPER: 0}, 1: {}, 2: {}}}
+		if !verifySpecialSetsHaveClaimedProperty(wfa, specialSets) {
+			t.Fail()
+		}
+	})
+}
+
 func TestVerifyStartConfigAccept(t *testing.T) {
 	t.Run("MissingConfig", func(t *testing.T) {
 		leftWFA := dwfa{startState: 0}
@@ -954,6 +1041,26 @@ func TestNextConfigsWithWeightChangeIsAccepted(t *testing.T) {
 			t.Fail()
 		}
 	})
+	t.Run("CorrectViaIntervals", func(t *testing.T) {
+		configWithWeight := configWithWeight{config{A, 0, 0, 0}, -3}
+		currentBounds := bounds{LOWER: 0}
+		leftSpecialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: -2}}}
+		rightSpecialSets := specialSets{intervals: map[wfaState]bounds{0: {LOWER: 1, UPPER: 4}}}
+		acceptSet := acceptSet{{A, 0, 0, 0}: {LOWER: -1}}
+		if !nextConfigWithWeightChangeIsAccepted(configWithWeight, currentBounds, leftSpecialSets, rightSpecialSets, acceptSet) {
+			t.Fail()
+		}
+	})
+	t.Run("CorrectViaUnreachableState", func(t *testing.T) {
+		configWithWeight := configWithWeight{config{A, 0, 0, 0}, 0}
+		currentBounds := bounds{}
+		leftSpecialSets := specialSets{intervals: map[wfaState]bounds{1: {}}}
+		rightSpecialSets := specialSets{intervals: map[wfaState]bounds{0: {}}}
+		acceptSet := acceptSet{}
+		if !nextConfigWithWeightChangeIsAccepted(configWithWeight, currentBounds, leftSpecialSets, rightSpecialSets, acceptSet) {
+			t.Fail()
+		}
+	})
 }
 
 func TestAcceptSetCountainsConfigBounds(t *testing.T) {
@@ -1067,6 +1174,70 @@ func TestAcceptSetCountainsConfigBounds(t *testing.T) {
 			t.Fail()
 		}
 	})
+	t.Run("CongruenceConflict", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {LOWER: 0, MODULUS: 2, RESIDUE: 0},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: 0, UPPER: 4}
+		if acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
+	t.Run("CorrectWithFinerCongruence", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {MODULUS: 2, RESIDUE: 1},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: -1, MODULUS: 4, RESIDUE: 3}
+		if !acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
+	t.Run("CorrectWithSingleWeight", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {MODULUS: 3, RESIDUE: 1},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: 4, UPPER: 4}
+		if !acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
+	t.Run("GapConflict", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {LOWER: 0, gapLower(0): 1, gapUpper(0): 4},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: 4, gapLower(0): 5, gapUpper(0): 6}
+		if acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
+	t.Run("CorrectWithGaps", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {LOWER: 0, gapLower(0): 1, gapUpper(0): 4},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: -2, UPPER: 6, gapLower(0): -1, gapUpper(0): 5}
+		if acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+		bound[LOWER] = 0
+		if !acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
+	t.Run("CorrectWithAlignedBounds", func(t *testing.T) {
+		acceptSet := map[config]bounds{
+			{A, 0, 0, 0}: {LOWER: 2, UPPER: 4},
+		}
+		config := config{A, 0, 0, 0}
+		bound := map[boundType]weight{LOWER: 1, UPPER: 5, MODULUS: 2, RESIDUE: 0}
+		if !acceptSetCountainsConfigBounds(acceptSet, config, bound) {
+			t.Fail()
+		}
+	})
 }
 
 func TestMITMWFARverifier(t *testing.T) {